@@ -5,7 +5,9 @@ import (
 	"content-creator-agent/models"
 	"content-creator-agent/tools"
 	"content-creator-agent/tools/logger"
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -20,6 +22,27 @@ type Agent struct {
 	Vector    memory.VectorStore
 	Embedding tools.EmbeddingTool
 	Analytics tools.AnalyticsFetcher
+
+	// Providers are additional named LLMs that brands can select with
+	// LLMProvider or per step with StepProviders.
+	Providers map[string]tools.LLMTool
+
+	// Prices values the tokens recorded in the usage ledger.
+	Prices tools.PriceTable
+
+	BeforeStep []StepHook // Called before every pipeline step
+	AfterStep  []StepHook // Called after every pipeline step
+
+	// OnDelta, when set, receives the text of every LLM call as it is
+	// generated. Providers that cannot stream deliver it in one piece.
+	OnDelta func(StreamEvent)
+
+	steps      map[string]StepFunc     // Custom pipeline steps registered on this agent
+	runAt      time.Time               // Start of the current run
+	rejections []models.GuardRejection // Guardrail rejections collected during the current run
+	trace      *models.RunTrace        // Trace of the current run
+	step       string                  // Pipeline step currently executing
+	variant    string                  // Variant being drafted, for stream events
 }
 
 func NewAgent(brand models.BrandProfile, search tools.SearchTool, llm tools.LLMTool, social tools.SocialClient, store memory.Store, vector memory.VectorStore, embedding tools.EmbeddingTool, analytics tools.AnalyticsFetcher) *Agent {
@@ -32,163 +55,102 @@ func NewAgent(brand models.BrandProfile, search tools.SearchTool, llm tools.LLMT
 		Vector:    vector,
 		Embedding: embedding,
 		Analytics: analytics,
+		Prices:    tools.DefaultPrices,
 	}
 }
 
 // Run executes one full cycle of the agent loop.
-func (a *Agent) Run() error {
+func (a *Agent) Run(ctx context.Context) (err error) {
 	logger.GlobalBuffer.Info("Starting autonomous loop for brand: %s", a.Brand.Name)
 
-	// 1. Research
-	logger.GlobalBuffer.Info("Step 1: Researching latest trends...")
-	query := fmt.Sprintf("latest trends in %s", a.Brand.Industry)
-	trends, err := a.Search.Search(query)
-	if err != nil {
-		return fmt.Errorf("research failed: %w", err)
-	}
-
-	// 2. Planning
-	logger.GlobalBuffer.Info("Step 2: Planning content strategy...")
-	plan, err := a.Plan(trends)
-	if err != nil {
-		return fmt.Errorf("planning failed: %w", err)
-	}
-	logger.GlobalBuffer.Info("Selected Topic: %s", plan)
-
-	// 3. Generation & Evaluation Loop
-	logger.GlobalBuffer.Info("Step 3: Generating and refining content...")
-	var finalPost *models.Post
-	for i := 0; i < 3; i++ { // Allow up to 3 iterations
-		draft, err := a.Generate(plan)
-		if err != nil {
-			return err
-		}
-
-		critique, score, err := a.Evaluate(draft)
-		if err != nil {
-			return err
-		}
+	a.beginRun(RunKindPublish)
+	defer func() { a.endRun(err) }()
 
-		logger.GlobalBuffer.Info("Draft Iteration %d (Score: %d/10)", i+1, score)
-		if score >= 8 {
-			finalPost = &models.Post{
-				ID:        fmt.Sprintf("post-%d", time.Now().Unix()),
-				BrandID:   a.Brand.ID,
-				Topic:     plan,
-				Content:   draft,
-				Platform:  "LinkedIn/X",
-				Status:    models.StatusApproved,
-				CreatedAt: time.Now(),
-			}
-			break
-		}
-		logger.GlobalBuffer.Warn("Feedback: %s", critique)
+	if err := a.runPipeline(ctx, &RunContext{Mode: ModePublish, PostCount: 1}); err != nil {
+		return err
 	}
 
-	if finalPost == nil {
-		return fmt.Errorf("failed to generate satisfactory content after 3 attempts")
-	}
+	logger.GlobalBuffer.Info("Autonomous cycle completed successfully!")
+	return nil
+}
 
-	// 4. Posting
-	logger.GlobalBuffer.Info("Step 4: Publishing...")
-	if err := a.Social.Post(finalPost); err != nil {
-		return fmt.Errorf("posting failed: %w", err)
-	}
+// RunForReview executes one cycle but saves the approved variants into the
+// calendar as pending_review instead of posting them. Once approved they are
+// published by the scheduler like any other scheduled post.
+func (a *Agent) RunForReview(ctx context.Context) (err error) {
+	logger.GlobalBuffer.Info("Starting review cycle for brand: %s", a.Brand.Name)
 
-	// 5. Memory
-	logger.GlobalBuffer.Info("Step 5: Saving to long-term memory...")
-	if err := a.Store.SavePost(*finalPost); err != nil {
-		return fmt.Errorf("memory storage failed: %w", err)
-	}
+	a.beginRun(RunKindReview)
+	defer func() { a.endRun(err) }()
 
-	// 5b. Vector Memory
-	if a.Embedding != nil && a.Vector != nil {
-		logger.GlobalBuffer.Info("Step 5b: Generating embeddings and indexing post...")
-		embedding, err := a.Embedding.Embed(finalPost.Content)
-		if err == nil {
-			a.Vector.Add(memory.VectorRecord{
-				ID:     finalPost.ID,
-				Vector: embedding,
-				Metadata: map[string]interface{}{
-					"topic":   finalPost.Topic,
-					"content": finalPost.Content,
-					"brand":   a.Brand.ID,
-				},
-			})
-		} else {
-			logger.GlobalBuffer.Error("Warning: Failed to create embedding: %v", err)
-		}
+	rc := &RunContext{Mode: ModeReview, PostCount: 1}
+	if err = a.runPipeline(ctx, rc); err != nil {
+		return err
 	}
 
-	logger.GlobalBuffer.Info("Autonomous cycle completed successfully!")
+	logger.GlobalBuffer.Info("📝 Cycle saved %d variants for approval", len(rc.Scheduled))
 	return nil
 }
 
-// PlanBatch researches and generates a series of posts to be scheduled for the future.
-func (a *Agent) PlanBatch(postCount int) error {
-	logger.GlobalBuffer.Info("🎯 Planning batch of %d posts for brand: %s", postCount, a.Brand.Name)
-
-	// 1. Research
-	logger.GlobalBuffer.Info("Step 1: Researching latest trends for batch...")
-	query := fmt.Sprintf("latest trends in %s", a.Brand.Industry)
-	trends, err := a.Search.Search(query)
-	if err != nil {
-		return fmt.Errorf("research failed: %w", err)
-	}
+// maxTopicSearches bounds the extra searches made for a brand's declared topics.
+const maxTopicSearches = 3
 
-	// 2. Generate multiple plans
-	var topics []string
-	for i := 0; i < postCount; i++ {
-		topic, err := a.Plan(trends)
+// research gathers trends for the brand's industry and declared topics, lists
+// trends that mention a declared topic first and drops anything touching an
+// anti-topic.
+func (a *Agent) research(ctx context.Context) ([]models.Trend, error) {
+	var trends []models.Trend
+	for i, topic := range a.Brand.Topics {
+		if i >= maxTopicSearches {
+			break
+		}
+		results, err := a.Search.Search(fmt.Sprintf("latest %s news", topic))
 		if err != nil {
-			return err
+			logger.GlobalBuffer.Warn("Topic search for %q failed: %v", topic, err)
+			continue
 		}
-		topics = append(topics, topic)
-		logger.GlobalBuffer.Info("Planned topic %d: %s", i+1, topic)
+		trends = append(trends, results...)
 	}
 
-	// 3. For each topic, generate and schedule
-	for i, topic := range topics {
-		logger.GlobalBuffer.Info("Step 3.%d: Generating content for: %s", i+1, topic)
-
-		var draft string
-		var score int
-		for retry := 0; retry < 3; retry++ {
-			draft, err = a.Generate(topic)
-			if err != nil {
-				return err
-			}
-			_, score, err = a.Evaluate(draft)
-			if err != nil {
-				return err
-			}
-			if score >= 7 {
-				break
-			}
-		}
+	query := fmt.Sprintf("latest trends in %s", a.Brand.Industry)
+	results, err := a.Search.Search(query)
+	if err != nil && len(trends) == 0 {
+		return nil, fmt.Errorf("research failed: %w", err)
+	}
+	trends = append(trends, results...)
 
-		// Schedule them evenly over the next week (simplified logic)
-		scheduleTime := time.Now().Add(time.Duration((i+1)*24) * time.Hour)
-
-		sp := models.ScheduledPost{
-			ID:          fmt.Sprintf("sp-%d-%d", time.Now().Unix(), i),
-			BrandID:     a.Brand.ID,
-			Topic:       topic,
-			Content:     draft,
-			Platform:    "LinkedIn/X",
-			Status:      models.StatusPending,
-			ScheduledAt: scheduleTime,
-			CreatedAt:   time.Now(),
+	seen := make(map[string]bool)
+	var unique []models.Trend
+	for _, t := range trends {
+		key := t.URL
+		if key == "" {
+			key = t.Title
 		}
-
-		if err := a.Store.SaveScheduledPost(sp); err != nil {
-			logger.GlobalBuffer.Error("Warning: Failed to save scheduled post: %v", err)
-		} else {
-			logger.GlobalBuffer.Info("✅ Scheduled post %d for %v", i+1, scheduleTime.Format(time.RFC822))
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, t)
 		}
 	}
+	topics := a.brandTopicRules()
+	sort.SliceStable(unique, func(i, j int) bool {
+		return matchesBrandTopic(topics, unique[i]) && !matchesBrandTopic(topics, unique[j])
+	})
+
+	safe := a.FilterTrends(ctx, unique)
+	if len(safe) == 0 {
+		return nil, fmt.Errorf("research found no trends that pass the brand guardrails")
+	}
+	return safe, nil
+}
 
-	return nil
+// PlanBatch researches and generates a series of posts to be scheduled for the future.
+func (a *Agent) PlanBatch(ctx context.Context, postCount int) (err error) {
+	logger.GlobalBuffer.Info("🎯 Planning batch of %d posts for brand: %s", postCount, a.Brand.Name)
+
+	a.beginRun(RunKindPlan)
+	defer func() { a.endRun(err) }()
+
+	return a.runPipeline(ctx, &RunContext{Mode: ModeSchedule, PostCount: postCount})
 }
 
 // PublishScheduledPost takes a previously planned post and pushes it to social media.
@@ -196,13 +158,20 @@ func (a *Agent) PublishScheduledPost(sp models.ScheduledPost) error {
 	logger.GlobalBuffer.Info("🚀 Publishing scheduled post: %s", sp.ID)
 
 	post := models.Post{
-		ID:        fmt.Sprintf("p-%d", time.Now().Unix()),
-		BrandID:   sp.BrandID,
-		Topic:     sp.Topic,
-		Content:   sp.Content,
-		Platform:  sp.Platform,
-		Status:    models.StatusPublished,
-		CreatedAt: time.Now(),
+		ID:         fmt.Sprintf("p-%d", time.Now().Unix()),
+		BrandID:    sp.BrandID,
+		Topic:      sp.Topic,
+		Content:    sp.Content,
+		SourceURL:  sp.SourceURL,
+		Pillar:     sp.Pillar,
+		Platform:   sp.Platform,
+		Locale:     sp.Locale,
+		Status:     models.StatusPublished,
+		Critique:   sp.Critique,
+		Iterations: sp.Iterations,
+		CreatedAt:  time.Now(),
+
+		PromptVersions: sp.PromptVersions,
 	}
 
 	if err := a.Social.Post(&post); err != nil {
@@ -213,87 +182,123 @@ func (a *Agent) PublishScheduledPost(sp models.ScheduledPost) error {
 	if err := a.Store.SavePost(post); err != nil {
 		return err
 	}
+	a.remember(post)
 
 	return a.Store.UpdateScheduledPostStatus(sp.ID, models.StatusPublished)
 }
 
-// Plan uses the LLM to select the best trend.
-func (a *Agent) Plan(trends []models.Trend) (string, error) {
-	var trendList []string
-	for _, t := range trends {
-		trendList = append(trendList, fmt.Sprintf("- %s: %s", t.Title, t.Snippet))
+// Plan uses the LLM to select the best trend. When pillar is set the topic must fit it.
+// Topics of the plans already made in this run are listed with the past topics
+// so that a batch does not repeat itself.
+func (a *Agent) Plan(ctx context.Context, trends []models.Trend, pillar *models.Pillar, planned []models.ContentPlan) (models.ContentPlan, error) {
+	data := planPrompt{Brand: a.Brand, Trends: trends, Pillar: pillar}
+	for _, p := range planned {
+		data.PastTopics = append(data.PastTopics, p.Topic)
 	}
 
 	history, _ := a.Store.GetHistory(a.Brand.ID)
-	var pastTopics []string
 	for _, p := range history {
-		pastTopics = append(pastTopics, p.Topic)
+		data.PastTopics = append(data.PastTopics, p.Topic)
 	}
 
-	// 2b. Semantic context
-	var semanticContext string
-	if a.Embedding != nil && a.Vector != nil {
-		queryEmbed, err := a.Embedding.Embed(fmt.Sprintf("content about %s in %s industry", a.Brand.Name, a.Brand.Industry))
-		if err == nil {
-			matches, _ := a.Vector.Query(queryEmbed, 3)
-			if len(matches) > 0 {
-				var contexts []string
-				for _, m := range matches {
-					contexts = append(contexts, fmt.Sprintf("- Past Topic: %s", m.Metadata["topic"]))
-				}
-				semanticContext = "\nRelevant semantic memories from past successes:\n" + strings.Join(contexts, "\n")
-			}
-		}
+	// 2b. Semantic context, re-ranked by engagement and recency
+	for _, m := range a.recall(a.recallQuery(trends), 3) {
+		data.Memories = append(data.Memories, promptMemory{Topic: fmt.Sprint(m.Metadata["topic"]), Engagement: m.Engagement})
 	}
 
-	systemPrompt := "You are a content strategist."
-	userPrompt := fmt.Sprintf(`Based on the following trends in %s, select ONE topic to write a high-relevance post about. 
-Trends:
-%s
-
-Past topics we covered: %s
-%s
-Avoid duplicating recent topics. Highlight why this topic is trending. Output ONLY the topic title.`,
-		a.Brand.Industry, strings.Join(trendList, "\n"), strings.Join(pastTopics, ", "), semanticContext)
+	if pillar != nil {
+		logger.GlobalBuffer.Info("Planning for content pillar: %s", pillar.Name)
+	}
 
-	return a.LLM.Generate(systemPrompt, userPrompt)
+	return generateJSON(ctx, a, PromptPlan, data, func(p *models.ContentPlan) error {
+		p.Topic = strings.TrimSpace(p.Topic)
+		if p.Topic == "" {
+			return fmt.Errorf("topic is required")
+		}
+		if strings.TrimSpace(p.Rationale) == "" {
+			return fmt.Errorf("rationale is required")
+		}
+		if p.SourceIndex < 0 || p.SourceIndex >= len(trends) {
+			return fmt.Errorf("source_index %d does not refer to a listed trend", p.SourceIndex)
+		}
+		source := trends[p.SourceIndex]
+		p.Source = &source
+		p.SourceTrend = source.Title
+		if pillar != nil {
+			p.Pillar = pillar.Name
+		}
+		return nil
+	})
 }
 
-// Generate creates the content draft.
-func (a *Agent) Generate(topic string) (string, error) {
-	systemPrompt := fmt.Sprintf("You are the Content Creator for %s. Your brand voice is: %s. Your audience is %s.",
-		a.Brand.Name, a.Brand.Voice, a.Brand.TargetAudience)
-
-	userPrompt := fmt.Sprintf("Write a professional and engaging social media post (approx 150 words) about: %s. Include relevant hashtags.", topic)
-
-	return a.LLM.Generate(systemPrompt, userPrompt)
+// Generate creates the content draft for a single platform and locale, grounded in the plan's source.
+func (a *Agent) Generate(ctx context.Context, plan models.ContentPlan, platform, locale string) (string, error) {
+	data := a.draftPrompt(plan, platform, locale)
+	data.Examples = a.fewShotBrief(platform, locale)
+	return a.generate(ctx, PromptGenerate, data)
 }
 
-// Evaluate provides a critique and score.
-func (a *Agent) Evaluate(content string) (string, int, error) {
-	systemPrompt := "You are a Brand Quality Critic. Your job is to ensure content matches brand voice and quality."
-	userPrompt := fmt.Sprintf(`Evaluate the following post for brand: %s. 
-Voice requirement: %s
-Target Audience: %s
-
-Post Content:
-"%s"
-
-Provide a critique and a score from 1 to 10. Format: "Critique: [text] Score: [number]"`,
-		a.Brand.Name, a.Brand.Voice, a.Brand.TargetAudience, content)
+// Revise rewrites a draft to address the critic's feedback.
+func (a *Agent) Revise(ctx context.Context, plan models.ContentPlan, draft string, critique models.Critique, platform, locale string) (string, error) {
+	data := a.draftPrompt(plan, platform, locale)
+	data.Draft = a.stripSource(draft, plan)
+	data.Critique = critique
+	return a.generate(ctx, PromptRevise, data)
+}
 
-	response, err := a.LLM.Generate(systemPrompt, userPrompt)
-	if err != nil {
-		return "", 0, err
+// draftPrompt fills the template data shared by Generate and Revise.
+func (a *Agent) draftPrompt(plan models.ContentPlan, platform, locale string) draftPrompt {
+	return draftPrompt{
+		Brand:       a.Brand,
+		Plan:        plan,
+		Platform:    a.specFor(platform),
+		Locale:      locale,
+		Language:    languageName(locale),
+		Budget:      a.textBudget(plan, platform),
+		SourceBrief: sourceBrief(plan),
+		LocaleBrief: localeBrief(locale),
 	}
+}
 
-	// Simple heuristic to extract score
-	score := 7 // Default if parsing fails
-	if strings.Contains(response, "Score:") {
-		fmt.Sscanf(strings.Split(response, "Score:")[1], "%d", &score)
+// Evaluate scores a draft against the brand rubric and the plan's source. For
+// localized variants the critic also reports the language the draft is in.
+func (a *Agent) Evaluate(ctx context.Context, plan models.ContentPlan, content, platform, locale string) (models.Critique, error) {
+	data := evaluatePrompt{
+		Brand:       a.Brand,
+		Plan:        plan,
+		Platform:    a.specFor(platform),
+		Locale:      locale,
+		SourceBrief: sourceBrief(plan),
+		Content:     content,
+	}
+	if locale != "" {
+		data.Language = languageName(locale)
 	}
 
-	return response, score, nil
+	return generateJSON(ctx, a, PromptEvaluate, data, func(c *models.Critique) error {
+		for _, dim := range []struct {
+			name  string
+			score int
+		}{
+			{"voice", c.Scores.Voice},
+			{"accuracy", c.Scores.Accuracy},
+			{"hook", c.Scores.Hook},
+			{"clarity", c.Scores.Clarity},
+			{"cta", c.Scores.CTA},
+		} {
+			if err := checkScore(dim.name, dim.score); err != nil {
+				return err
+			}
+		}
+		if strings.TrimSpace(c.Feedback) == "" {
+			return fmt.Errorf("feedback is required")
+		}
+		if locale != "" && strings.TrimSpace(c.Language) == "" {
+			return fmt.Errorf("language is required")
+		}
+		c.Score = c.Scores.Overall()
+		return nil
+	})
 }
 
 // SyncAnalytics fetches latest performance data for past posts and updates memory.
@@ -327,7 +332,7 @@ func (a *Agent) SyncAnalytics() error {
 				"likes":    metrics.Likes,
 				"shares":   metrics.Shares,
 				"comments": metrics.Comments,
-				"score":    metrics.Likes + (metrics.Shares * 2), // Simple performance score
+				"score":    engagementScore(metrics), // Simple performance score
 			})
 		}
 	}
@@ -351,7 +356,7 @@ func (a *Agent) Start(interval time.Duration) {
 
 func (a *Agent) runAndSync() {
 	logger.GlobalBuffer.Info("\n--- [%s] Starting Autonomous Cycle ---", time.Now().Format(time.RFC822))
-	if err := a.Run(); err != nil {
+	if err := a.Run(context.Background()); err != nil {
 		logger.GlobalBuffer.Error("Cycle error: %v", err)
 	}
 
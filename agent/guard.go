@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools/logger"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Guardrail stages recorded on rejections.
+const (
+	GuardStageTrend = "trend"
+	GuardStageDraft = "draft"
+)
+
+// Guardrail methods recorded on rejections.
+const (
+	GuardMethodKeyword    = "keyword"
+	GuardMethodClassifier = "classifier"
+)
+
+// Boundaries around keyword topics and anti-topics: the text edge or a
+// character that is not a letter, digit or underscore.
+const (
+	keywordBoundary = `(?:^|[^\p{L}\p{N}_])`
+	keywordEnd      = `(?:$|[^\p{L}\p{N}_])`
+)
+
+// topicRule is a compiled matcher for one brand topic or anti-topic.
+type topicRule struct {
+	topic string
+	re    *regexp.Regexp
+}
+
+// antiTopicRules compiles the brand's anti-topics.
+func (a *Agent) antiTopicRules() []topicRule {
+	return compileTopicRules("anti-topic", a.Brand.AntiTopics)
+}
+
+// brandTopicRules compiles the brand's focus topics.
+func (a *Agent) brandTopicRules() []topicRule {
+	return compileTopicRules("topic", a.Brand.Topics)
+}
+
+// compileTopicRules compiles topics of the given kind. Entries written as
+// /pattern/ are treated as regular expressions, everything else as a keyword
+// that must not be part of a longer word. Matching is case-insensitive.
+func compileTopicRules(kind string, topics []string) []topicRule {
+	var rules []topicRule
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+
+		// \b only sits between a word and a non-word character, so it never
+		// matches around keywords such as "C++" or "#crypto".
+		pattern := keywordBoundary + "(" + regexp.QuoteMeta(topic) + ")" + keywordEnd
+		if len(topic) > 2 && strings.HasPrefix(topic, "/") && strings.HasSuffix(topic, "/") {
+			pattern = "(" + topic[1:len(topic)-1] + ")"
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			logger.GlobalBuffer.Warn("Ignoring invalid %s pattern %q: %v", kind, topic, err)
+			continue
+		}
+		rules = append(rules, topicRule{topic: topic, re: re})
+	}
+	return rules
+}
+
+// matchTopic returns the first rule matching text and the matched text.
+func matchTopic(rules []topicRule, text string) (topicRule, string, bool) {
+	for _, rule := range rules {
+		if m := rule.re.FindStringSubmatch(text); m != nil && m[1] != "" {
+			return rule, m[1], true
+		}
+	}
+	return topicRule{}, "", false
+}
+
+// matchAntiTopic runs the deterministic keyword/regex pass over text.
+func (a *Agent) matchAntiTopic(text string) (string, bool) {
+	rule, matched, ok := matchTopic(a.antiTopicRules(), text)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s (matched %q)", rule.topic, matched), true
+}
+
+// reject logs a guardrail rejection and keeps it for the current run.
+func (a *Agent) reject(stage, subject, antiTopic, reason, method string) models.GuardRejection {
+	r := models.GuardRejection{
+		BrandID:   a.Brand.ID,
+		RunAt:     a.runAt,
+		Stage:     stage,
+		Subject:   subject,
+		AntiTopic: antiTopic,
+		Reason:    reason,
+		Method:    method,
+		CreatedAt: time.Now(),
+	}
+	if a.trace != nil {
+		r.RunID = a.trace.ID
+	}
+	a.rejections = append(a.rejections, r)
+	logger.GlobalBuffer.Warn("🛡️ Guardrail rejected %s %q [%s]: %s", stage, truncate(subject, 80), method, reason)
+	return r
+}
+
+// flushRejections persists the rejections collected during the current run.
+func (a *Agent) flushRejections() {
+	if len(a.rejections) == 0 {
+		return
+	}
+	if err := a.Store.SaveGuardRejections(a.Brand.ID, a.rejections); err != nil {
+		logger.GlobalBuffer.Error("Warning: Failed to save guardrail rejections: %v", err)
+	}
+	a.rejections = nil
+}
+
+type trendVerdicts struct {
+	Rejected []struct {
+		Index     int    `json:"index"`
+		AntiTopic string `json:"anti_topic"`
+		Reason    string `json:"reason"`
+	} `json:"rejected"`
+}
+
+// FilterTrends drops trends that touch the brand's anti-topics. The keyword pass
+// runs first; the remaining trends are then checked by the LLM classifier in one
+// call. A classifier failure is logged and leaves the keyword result in place.
+func (a *Agent) FilterTrends(ctx context.Context, trends []models.Trend) []models.Trend {
+	if len(a.Brand.AntiTopics) == 0 {
+		return trends
+	}
+
+	var kept []models.Trend
+	for _, t := range trends {
+		if rule, ok := a.matchAntiTopic(t.Title + " " + t.Snippet); ok {
+			a.reject(GuardStageTrend, t.Title, rule, "trend matches anti-topic "+rule, GuardMethodKeyword)
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if len(kept) == 0 {
+		return kept
+	}
+
+	verdicts, err := generateJSON(ctx, a, PromptTrendGuard, guardPrompt{Brand: a.Brand, Trends: kept}, func(v *trendVerdicts) error {
+		for _, r := range v.Rejected {
+			if r.Index < 0 || r.Index >= len(kept) {
+				return fmt.Errorf("index %d is out of range", r.Index)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.GlobalBuffer.Warn("Trend classifier failed, relying on keyword guardrails: %v", err)
+		return kept
+	}
+
+	dropped := make(map[int]bool)
+	for _, r := range verdicts.Rejected {
+		if dropped[r.Index] {
+			continue
+		}
+		dropped[r.Index] = true
+		a.reject(GuardStageTrend, kept[r.Index].Title, r.AntiTopic, r.Reason, GuardMethodClassifier)
+	}
+
+	var safe []models.Trend
+	for i, t := range kept {
+		if !dropped[i] {
+			safe = append(safe, t)
+		}
+	}
+	return safe
+}
+
+type draftVerdict struct {
+	Violates  bool   `json:"violates"`
+	AntiTopic string `json:"anti_topic"`
+	Reason    string `json:"reason"`
+}
+
+// CheckDraft rejects drafts that touch the brand's anti-topics. It returns the
+// rejection, or nil when the draft is safe. Classifier errors are returned so
+// that an unchecked draft is never approved.
+func (a *Agent) CheckDraft(ctx context.Context, draft, platform string) (*models.GuardRejection, error) {
+	if len(a.Brand.AntiTopics) == 0 {
+		return nil, nil
+	}
+
+	subject := fmt.Sprintf("[%s] %s", platform, draft)
+	if rule, ok := a.matchAntiTopic(draft); ok {
+		r := a.reject(GuardStageDraft, subject, rule, "draft mentions anti-topic "+rule, GuardMethodKeyword)
+		return &r, nil
+	}
+
+	verdict, err := generateJSON(ctx, a, PromptDraftGuard, guardPrompt{Brand: a.Brand, Draft: draft, Platform: platform}, func(v *draftVerdict) error {
+		if v.Violates && strings.TrimSpace(v.Reason) == "" {
+			return fmt.Errorf("reason is required when violates is true")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("draft guardrail classifier failed: %w", err)
+	}
+	if !verdict.Violates {
+		return nil, nil
+	}
+
+	r := a.reject(GuardStageDraft, subject, verdict.AntiTopic, verdict.Reason, GuardMethodClassifier)
+	return &r, nil
+}
+
+// matchesBrandTopic reports whether a trend mentions one of the topics, with
+// the same boundaries as anti-topics.
+func matchesBrandTopic(topics []topicRule, t models.Trend) bool {
+	_, _, ok := matchTopic(topics, t.Title+" "+t.Snippet)
+	return ok
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"testing"
+)
+
+func TestMatchAntiTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		text  string
+		match string // Empty when the text must pass
+	}{
+		{"crypto", "Why crypto is back", "crypto"},
+		{"crypto", "CRYPTO winter", "CRYPTO"},
+		{"crypto", "cryptography basics", ""},
+		{"crypto", "crypto_wallet", ""},
+		{"C++", "Modern C++ tips", "C++"},
+		{"C++", "c++ in 2024", "c++"},
+		{"C++", "C# tips", ""},
+		{"C++", "learn C++20 today", ""},
+		{".NET", "Porting to .NET 8", ".NET"},
+		{".NET", "ASP.NET Core", ""},
+		{"#crypto", "Trending: #crypto!", "#crypto"},
+		{"#crypto", "#cryptopunks drop", ""},
+		{"AT&T", "AT&T outage", "AT&T"},
+		{"AT&T", "(at&t) earnings", "at&t"},
+		{"AT&T", "AT&Tx", ""},
+		{"layoffs", "Layoffs.", "Layoffs"},
+		{"/lay-?offs?/", "a layoff wave", "layoff"},
+		{"/^breaking/", "Breaking: news", "Breaking"},
+		{"/^breaking/", "not breaking", ""},
+	}
+	for _, tt := range tests {
+		a := &Agent{Brand: models.BrandProfile{AntiTopics: []string{tt.topic}}}
+		reason, ok := a.matchAntiTopic(tt.text)
+		if tt.match == "" {
+			if ok {
+				t.Errorf("%q in %q: unexpected match %s", tt.topic, tt.text, reason)
+			}
+			continue
+		}
+		want := tt.topic + ` (matched "` + tt.match + `")`
+		if !ok || reason != want {
+			t.Errorf("%q in %q: got %q, %v; want %q", tt.topic, tt.text, reason, ok, want)
+		}
+	}
+}
+
+func TestAntiTopicRulesSkipInvalidPatterns(t *testing.T) {
+	a := &Agent{Brand: models.BrandProfile{AntiTopics: []string{"", "  ", "/(/", "gambling"}}}
+	rules := a.antiTopicRules()
+	if len(rules) != 1 || rules[0].topic != "gambling" {
+		t.Fatalf("got %d rules, want only gambling", len(rules))
+	}
+}
+
+func TestMatchesBrandTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		text  string
+		want  bool
+	}{
+		{"AI", "How AI changes support", true},
+		{"AI", "ai-assisted code review", true},
+		{"AI", "Tips to maintain legacy code", false},
+		{"AI", "Said the CEO", false},
+		{"Go", "Go 1.23 released", true},
+		{"Go", "Google announces", false},
+		{"C++", "What's new in C++26", false},
+		{"C++", "Modern C++: what's new", true},
+		{"/gen(erative)? ai/", "Generative AI funding", true},
+	}
+	for _, tt := range tests {
+		a := &Agent{Brand: models.BrandProfile{Topics: []string{tt.topic}}}
+		if got := matchesBrandTopic(a.brandTopicRules(), models.Trend{Title: tt.text}); got != tt.want {
+			t.Errorf("%q in %q = %v, want %v", tt.topic, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languageNames maps primary language subtags to names the models understand.
+var languageNames = map[string]string{
+	"am": "Amharic",
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+	"sw": "Swahili",
+	"zh": "Chinese",
+}
+
+// locales returns the locales this run should produce variants for. Brands
+// without locales get a single variant in the model's default language.
+func (a *Agent) locales() []string {
+	var result []string
+	for _, l := range a.Brand.Locales {
+		if l = strings.TrimSpace(l); l != "" {
+			result = append(result, l)
+		}
+	}
+	if len(result) == 0 {
+		return []string{""}
+	}
+	return result
+}
+
+// primaryLanguage returns the lower-cased language subtag of a locale, e.g. "es" for "es-MX".
+func primaryLanguage(locale string) string {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if i := strings.Index(locale, "-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(locale))
+}
+
+// languageName describes a locale for prompts, e.g. "Spanish (es-MX)".
+func languageName(locale string) string {
+	if name, ok := languageNames[primaryLanguage(locale)]; ok {
+		return fmt.Sprintf("%s (%s)", name, locale)
+	}
+	return locale
+}
+
+// sameLanguage reports whether two language tags share a primary language.
+func sameLanguage(a, b string) bool {
+	return primaryLanguage(a) == primaryLanguage(b)
+}
+
+// localeBrief tells the writer which market a variant is for. Variants are
+// adapted rather than translated.
+func localeBrief(locale string) string {
+	if locale == "" {
+		return ""
+	}
+	return fmt.Sprintf(`Language and market: write natively in %s for readers in that market. Adapt idioms, examples, references and hashtags so the post feels written there; do not translate literally from English.
+`, languageName(locale))
+}
+
+// label names a variant in logs, e.g. "twitter" or "twitter/es-MX".
+func (v *Variant) label() string {
+	if v.Locale == "" {
+		return v.Platform
+	}
+	return v.Platform + "/" + v.Locale
+}
+
+// key identifies a variant in post IDs, e.g. "twitter" or "twitter-es-MX".
+func (v *Variant) key() string {
+	if v.Locale == "" {
+		return v.Platform
+	}
+	return v.Platform + "-" + v.Locale
+}
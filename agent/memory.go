@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"content-creator-agent/tools/logger"
+	"fmt"
+	"time"
+)
+
+// Defaults for semantic duplicate detection when the brand sets none.
+const (
+	DefaultDuplicateThreshold    = 0.9
+	DefaultDuplicateLookbackDays = 30
+)
+
+// duplicateCandidates is how many nearest neighbours are inspected per draft.
+const duplicateCandidates = 10
+
+// embeddingModelKey is the vector metadata naming the model a record was
+// embedded with. Vectors are only compared with vectors of the same model;
+// records without it predate the tag and are always compared.
+const embeddingModelKey = "embedding_model"
+
+// duplicateMatch describes a past post that a draft is too close to.
+type duplicateMatch struct {
+	ID         string
+	Topic      string
+	Similarity float32
+	CreatedAt  time.Time
+}
+
+// remember indexes a published post in vector memory. When the embedder has
+// a local fallback the post is indexed with that too, so that duplicate
+// detection and recall keep working while the primary embedder is down.
+func (a *Agent) remember(post models.Post) {
+	if a.Embedding == nil || a.Vector == nil {
+		return
+	}
+	embeddings, model, err := a.embed(tools.TaskRetrievalDocument, post.Content)
+	if err != nil {
+		logger.GlobalBuffer.Error("Warning: Failed to create embedding: %v", err)
+		return
+	}
+	a.index(post, embeddings[0], model)
+
+	fallback, ok := a.Embedding.(*tools.FallbackEmbedding)
+	if !ok || tools.ModelName(fallback.Fallback) == model {
+		return
+	}
+	embeddings, model, err = a.embedWith(fallback.Fallback, tools.TaskRetrievalDocument, post.Content)
+	if err != nil {
+		logger.GlobalBuffer.Warn("Failed to index post %s with the fallback embedder: %v", post.ID, err)
+		return
+	}
+	a.index(post, embeddings[0], model)
+}
+
+// index adds one vector of a post to vector memory.
+func (a *Agent) index(post models.Post, vector []float32, model string) {
+	a.Vector.Add(memory.VectorRecord{
+		ID:     post.ID,
+		Vector: vector,
+		Metadata: map[string]interface{}{
+			"topic":           post.Topic,
+			"content":         post.Content,
+			"brand":           a.Brand.ID,
+			"platform":        post.Platform,
+			"created_at":      post.CreatedAt.Format(time.RFC3339),
+			embeddingModelKey: model,
+		},
+	})
+}
+
+// sameEmbedding reports whether a match was embedded with model.
+func sameEmbedding(m memory.SearchResult, model string) bool {
+	tag, ok := m.Metadata[embeddingModelKey].(string)
+	return !ok || tag == model
+}
+
+// findDuplicates embeds drafts in one batch and, for each, looks for a post in
+// the brand's vector memory that is at least as similar as the brand threshold
+// and falls inside the lookback window. The result holds the match or nil per
+// draft. Records without a timestamp predate tracking and are always compared.
+// Drafts are embedded as queries so that the local embedder only learns from
+// published posts. Embedding failures skip the check rather than failing the run.
+func (a *Agent) findDuplicates(drafts []string) []*duplicateMatch {
+	found := make([]*duplicateMatch, len(drafts))
+	if a.Embedding == nil || a.Vector == nil || len(drafts) == 0 {
+		return found
+	}
+
+	threshold := a.Brand.DuplicateThreshold
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+	lookback := a.Brand.DuplicateLookbackDays
+	if lookback <= 0 {
+		lookback = DefaultDuplicateLookbackDays
+	}
+	since := time.Now().AddDate(0, 0, -lookback)
+
+	embeddings, model, err := a.embed(tools.TaskRetrievalQuery, drafts...)
+	if err != nil {
+		logger.GlobalBuffer.Warn("Skipping duplicate check, failed to embed drafts: %v", err)
+		return found
+	}
+	for i, embedding := range embeddings {
+		matches, err := a.Vector.Query(embedding, duplicateCandidates)
+		if err != nil {
+			logger.GlobalBuffer.Warn("Skipping duplicate check, vector query failed: %v", err)
+			return found
+		}
+		found[i] = a.closestDuplicate(matches, model, threshold, since)
+	}
+	return found
+}
+
+// closestDuplicate returns the first of the brand's matches embedded with
+// model that reaches the threshold and was posted after since.
+func (a *Agent) closestDuplicate(matches []memory.SearchResult, model string, threshold float64, since time.Time) *duplicateMatch {
+	for _, m := range matches {
+		if float64(m.Score) < threshold {
+			break // Results are sorted by similarity
+		}
+		if brand, ok := m.Metadata["brand"].(string); ok && brand != a.Brand.ID {
+			continue
+		}
+		if !sameEmbedding(m, model) {
+			continue
+		}
+		var createdAt time.Time
+		if ts, ok := m.Metadata["created_at"].(string); ok {
+			createdAt, _ = time.Parse(time.RFC3339, ts)
+			if createdAt.Before(since) {
+				continue
+			}
+		}
+		topic, _ := m.Metadata["topic"].(string)
+		return &duplicateMatch{ID: m.ID, Topic: topic, Similarity: m.Score, CreatedAt: createdAt}
+	}
+	return nil
+}
+
+// issue explains the duplicate so the draft can be revised into a new angle.
+func (d *duplicateMatch) issue() string {
+	when := "recently"
+	if !d.CreatedAt.IsZero() {
+		when = "on " + d.CreatedAt.Format("Jan 2")
+	}
+	return fmt.Sprintf("The post is %.0f%% similar to what we published %s about %q. Take a clearly different angle, hook and examples.",
+		d.Similarity*100, when, d.Topic)
+}
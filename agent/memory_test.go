@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestOnlyPublishedPostsTrainLocalEmbedding checks that duplicate checks on
+// drafts leave the local vocabulary alone and remembering a post updates it.
+func TestOnlyPublishedPostsTrainLocalEmbedding(t *testing.T) {
+	dir := t.TempDir()
+	vocabPath := filepath.Join(dir, "vocabulary.json")
+	embedding := tools.NewLocalEmbedding(vocabPath)
+	a := NewAgent(models.BrandProfile{ID: "brand"}, nil, nil, nil,
+		memory.NewFileStore(dir), memory.NewLocalVectorStore(filepath.Join(dir, "vectors.json")), embedding, nil)
+
+	post := models.Post{ID: "p1", Topic: "Go", Content: "Go 1.23 ships range over func iterators", CreatedAt: time.Now()}
+	if found := a.findDuplicates([]string{post.Content, "A rejected draft about Rust"}); found[0] != nil || found[1] != nil {
+		t.Errorf("duplicates found in empty memory: %+v", found)
+	}
+	if _, err := os.Stat(vocabPath); !os.IsNotExist(err) {
+		t.Fatalf("checking drafts saved the vocabulary: %v", err)
+	}
+
+	a.remember(post)
+	if _, err := os.Stat(vocabPath); err != nil {
+		t.Fatalf("remembering a post did not save the vocabulary: %v", err)
+	}
+	if found := a.findDuplicates([]string{post.Content}); found[0] == nil || found[0].ID != post.ID {
+		t.Errorf("draft repeating a published post not flagged: %+v", found[0])
+	}
+
+	saved, _ := os.ReadFile(vocabPath)
+	a.findDuplicates([]string{"Another draft entirely"})
+	if again, _ := os.ReadFile(vocabPath); string(again) != string(saved) {
+		t.Error("checking drafts changed the saved vocabulary")
+	}
+}
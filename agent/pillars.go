@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"sort"
+)
+
+// pillarWindow is how many recent topics the planner weighs against the pillar targets.
+const pillarWindow = 20
+
+// PillarMix compares the brand's pillar targets with the topics actually
+// posted. Each topic counts once, however many platform variants it had.
+// Pillars that are no longer configured are reported with a zero target.
+func PillarMix(brand models.BrandProfile, history []models.Post) []models.PillarShare {
+	total := 0
+	for _, p := range brand.Pillars {
+		total += p.Share
+	}
+
+	var mix []models.PillarShare
+	index := make(map[string]int)
+	for _, p := range brand.Pillars {
+		target := 0.0
+		if total > 0 {
+			target = float64(p.Share) * 100 / float64(total)
+		}
+		index[p.Name] = len(mix)
+		mix = append(mix, models.PillarShare{Pillar: p.Name, Target: target})
+	}
+
+	seen := make(map[string]bool)
+	counted := 0
+	for _, post := range history {
+		if post.Pillar == "" {
+			continue
+		}
+		i, ok := index[post.Pillar]
+		if !ok {
+			i = len(mix)
+			index[post.Pillar] = i
+			mix = append(mix, models.PillarShare{Pillar: post.Pillar})
+		}
+		share := &mix[i]
+		share.Likes += post.Analytics.Likes
+		share.Shares += post.Analytics.Shares
+		share.Comments += post.Analytics.Comments
+
+		if key := pillarTopic(post); !seen[key] {
+			seen[key] = true
+			share.Posts++
+			counted++
+		}
+	}
+
+	for i := range mix {
+		if counted > 0 {
+			mix[i].Actual = float64(mix[i].Posts) * 100 / float64(counted)
+		}
+	}
+	return mix
+}
+
+// pillarTopic is the key a post is counted under in the pillar mix.
+func pillarTopic(post models.Post) string {
+	return post.Pillar + "\x00" + post.Topic
+}
+
+// nextPillar picks the pillar that is furthest behind its target across the
+// brand's recent history and the plans already made in this run. It returns
+// nil when the brand has no pillars.
+func (a *Agent) nextPillar(planned []models.ContentPlan) *models.Pillar {
+	if len(a.Brand.Pillars) == 0 {
+		return nil
+	}
+
+	history, _ := a.Store.GetHistory(a.Brand.ID)
+	recent := append([]models.Post(nil), history...)
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].CreatedAt.After(recent[j].CreatedAt) })
+
+	var window []models.Post
+	for _, p := range planned {
+		window = append(window, models.Post{Topic: p.Topic, Pillar: p.Pillar})
+	}
+	topics := make(map[string]bool)
+	for _, p := range recent {
+		if p.Pillar == "" || topics[pillarTopic(p)] {
+			continue
+		}
+		if len(topics) >= pillarWindow {
+			break
+		}
+		topics[pillarTopic(p)] = true
+		window = append(window, p)
+	}
+
+	mix := PillarMix(a.Brand, window)
+	best := 0
+	for i := range a.Brand.Pillars {
+		if mix[i].Target-mix[i].Actual > mix[best].Target-mix[best].Actual {
+			best = i
+		}
+	}
+	return &a.Brand.Pillars[best]
+}
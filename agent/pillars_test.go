@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"testing"
+	"time"
+)
+
+// TestNextPillarCountsLikePillarMix checks that the planner counts a topic
+// posted under two pillars once per pillar, as the analytics do.
+func TestNextPillarCountsLikePillarMix(t *testing.T) {
+	store := memory.NewFileStore(t.TempDir())
+	brand := models.BrandProfile{ID: "brand", Pillars: []models.Pillar{
+		{Name: "A", Share: 1}, {Name: "B", Share: 1}, {Name: "C", Share: 1},
+	}}
+	now := time.Now()
+	for i, p := range []models.Post{
+		{Topic: "X", Pillar: "A"},
+		{Topic: "X", Pillar: "B"},
+		{Topic: "Y", Pillar: "C"},
+		{Topic: "Z", Pillar: "C"},
+	} {
+		p.ID = string(rune('a' + i))
+		p.BrandID = brand.ID
+		p.CreatedAt = now.Add(-time.Duration(i) * time.Hour)
+		if err := store.SavePost(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, _ := store.GetHistory(brand.ID)
+	mix := PillarMix(brand, history)
+	if mix[0].Posts != 1 || mix[1].Posts != 1 || mix[2].Posts != 2 {
+		t.Fatalf("mix = %+v", mix)
+	}
+
+	a := &Agent{Brand: brand, Store: store}
+	if got := a.nextPillar(nil); got.Name != "A" {
+		t.Errorf("next pillar = %s, want A, tied with B as the mix reports", got.Name)
+	}
+	if got := a.nextPillar([]models.ContentPlan{{Topic: "W", Pillar: "A"}}); got.Name != "B" {
+		t.Errorf("next pillar after planning A = %s, want B", got.Name)
+	}
+}
@@ -0,0 +1,536 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools/logger"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Pipeline step names. Brands list these in BrandProfile.Pipeline to enable,
+// disable or reorder steps.
+const (
+	StepResearch = "research"
+	StepPlan     = "plan"
+	StepGenerate = "generate"
+	StepGuard    = "guard"
+	StepEvaluate = "evaluate"
+	StepPublish  = "publish"
+	StepRemember = "remember"
+)
+
+// DefaultPipeline is used when the brand does not configure its own steps.
+var DefaultPipeline = []string{StepResearch, StepPlan, StepGenerate, StepGuard, StepEvaluate, StepPublish, StepRemember}
+
+// DefaultMinScore is the critic score a draft needs when the brand sets none.
+const DefaultMinScore = 8
+
+// maxDraftRounds bounds how often a draft is revised.
+const maxDraftRounds = 3
+
+// RunMode selects what the publish step does with the finished variants.
+type RunMode string
+
+const (
+	ModePublish  RunMode = "publish"  // Post approved variants immediately
+	ModeSchedule RunMode = "schedule" // Save variants as scheduled posts for later
+	ModeReview   RunMode = "review"   // Save approved variants for human approval, due immediately
+)
+
+// StepFunc is the body of a pipeline step.
+type StepFunc func(a *Agent, rc *RunContext) error
+
+// StepHook runs before or after every pipeline step. Returning an error aborts the run.
+type StepHook func(step string, rc *RunContext) error
+
+// builtinSteps maps step names to their implementations.
+var builtinSteps = map[string]StepFunc{
+	StepResearch: researchStep,
+	StepPlan:     planStep,
+	StepGenerate: generateStep,
+	StepGuard:    guardStep,
+	StepEvaluate: evaluateStep,
+	StepPublish:  publishStep,
+	StepRemember: rememberStep,
+}
+
+// sideEffectSteps are skipped in dry runs.
+var sideEffectSteps = map[string]bool{
+	StepPublish:  true,
+	StepRemember: true,
+}
+
+// draftingSteps form the revision loop. Every step from the first to the last
+// of them in the configured order is repeated while drafts still need work.
+var draftingSteps = map[string]bool{
+	StepGenerate: true,
+	StepGuard:    true,
+	StepEvaluate: true,
+}
+
+// RunContext is the state shared by the steps of one pipeline run.
+type RunContext struct {
+	Mode      RunMode
+	PostCount int             // Number of topics to plan
+	DryRun    bool            // Skip the publish and remember steps
+	Ctx       context.Context // Cancels the run's LLM calls; set by the pipeline
+	StartedAt time.Time
+	Round     int // Current revision round, starting at 0
+
+	Trends    []models.Trend
+	Plans     []models.ContentPlan
+	Variants  []*Variant
+	Posts     []*models.Post         // Posts created by a publishing run
+	Scheduled []models.ScheduledPost // Posts saved by a scheduling run
+}
+
+// Variant is one platform draft for a plan as it moves through the pipeline.
+type Variant struct {
+	PlanIndex  int
+	Plan       models.ContentPlan
+	Platform   string
+	Locale     string // Empty when the brand has no locales
+	Draft      string
+	Critique   *models.Critique // nil if the current draft has not been evaluated
+	Iterations []models.Iteration
+	Approved   bool // Whether the final draft passed every check
+	Blocked    bool // Whether the current draft was rejected by the guardrails
+	Duplicate  bool // Whether the current draft repeats a recent post
+
+	done     bool // No further revisions will be made
+	rejected bool // The current draft failed a check and needs revising
+}
+
+// Reject records why the current draft failed and sends it back for revision.
+// Custom steps placed inside the revision loop use it to add their own checks.
+func (v *Variant) Reject(issue string) {
+	v.rejected = true
+	v.Iterations = append(v.Iterations, models.Iteration{Draft: v.Draft, Issue: issue})
+}
+
+// Pending returns the variants whose current draft has not failed a check yet.
+func (rc *RunContext) Pending() []*Variant {
+	var pending []*Variant
+	for _, v := range rc.Variants {
+		if !v.done && !v.rejected {
+			pending = append(pending, v)
+		}
+	}
+	return pending
+}
+
+// settle approves drafts that made it through a round. It reports whether any
+// draft still needs revising.
+func (rc *RunContext) settle() bool {
+	open := false
+	for _, v := range rc.Variants {
+		switch {
+		case v.done:
+		case v.rejected:
+			open = true
+		default:
+			v.Approved = true
+			v.done = true
+		}
+	}
+	return open
+}
+
+// RegisterStep makes a custom step available to brand pipelines under name.
+// It replaces the built-in step of the same name, if any.
+func (a *Agent) RegisterStep(name string, fn StepFunc) {
+	if a.steps == nil {
+		a.steps = make(map[string]StepFunc)
+	}
+	a.steps[name] = fn
+}
+
+type namedStep struct {
+	name string
+	fn   StepFunc
+}
+
+// pipeline resolves the brand's step list.
+func (a *Agent) pipeline() ([]namedStep, error) {
+	names := a.Brand.Pipeline
+	if len(names) == 0 {
+		names = DefaultPipeline
+	}
+
+	var steps []namedStep
+	for _, name := range names {
+		fn, ok := a.steps[name]
+		if !ok {
+			fn, ok = builtinSteps[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown pipeline step %q", name)
+		}
+		steps = append(steps, namedStep{name: name, fn: fn})
+	}
+	return steps, nil
+}
+
+// ValidatePipeline checks that a brand pipeline only names built-in steps.
+// Steps added with RegisterStep exist only on their agent and can't be
+// checked when a brand is saved.
+func ValidatePipeline(names []string) error {
+	for _, name := range names {
+		if _, ok := builtinSteps[name]; !ok {
+			return fmt.Errorf("unknown pipeline step %q", name)
+		}
+	}
+	return nil
+}
+
+// minScore returns the critic score a draft needs to be approved.
+func (a *Agent) minScore() int {
+	if a.Brand.MinScore > 0 {
+		return a.Brand.MinScore
+	}
+	return DefaultMinScore
+}
+
+// runPipeline executes the brand's steps against rc, repeating the drafting
+// steps until every draft is approved or out of revisions.
+func (a *Agent) runPipeline(ctx context.Context, rc *RunContext) error {
+	steps, err := a.pipeline()
+	if err != nil {
+		return err
+	}
+
+	first, last := -1, -1
+	for i, s := range steps {
+		if draftingSteps[s.name] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	rc.StartedAt = a.runAt
+	rc.Ctx = ctx
+	for i := 0; i < len(steps); i++ {
+		s := steps[i]
+		if rc.DryRun && sideEffectSteps[s.name] {
+			logger.GlobalBuffer.Info("Dry run: skipping %s", s.name)
+			continue
+		}
+		if rc.Round == 0 || i < first || i > last {
+			logger.GlobalBuffer.Info("Step %d: %s", i+1, s.name)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("run cancelled before %s: %w", s.name, err)
+		}
+		if err := a.runStep(s, rc); err != nil {
+			return err
+		}
+
+		if i == last {
+			if rc.settle() && rc.Round+1 < maxDraftRounds {
+				rc.Round++
+				i = first - 1
+				continue
+			}
+			for _, v := range rc.Variants {
+				v.done = true
+			}
+		}
+	}
+	return nil
+}
+
+// runStep executes one step with its hooks and records it in the run trace.
+func (a *Agent) runStep(s namedStep, rc *RunContext) (err error) {
+	a.step = s.name
+	started := time.Now()
+	defer func() {
+		a.traceStep(s.name, rc.Round, started, err)
+		a.step = ""
+	}()
+
+	for _, hook := range a.BeforeStep {
+		if err := hook(s.name, rc); err != nil {
+			return fmt.Errorf("before %s hook: %w", s.name, err)
+		}
+	}
+	if err := s.fn(a, rc); err != nil {
+		return err
+	}
+	for _, hook := range a.AfterStep {
+		if err := hook(s.name, rc); err != nil {
+			return fmt.Errorf("after %s hook: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
+func researchStep(a *Agent, rc *RunContext) error {
+	trends, err := a.research(rc.Ctx)
+	if err != nil {
+		return err
+	}
+	rc.Trends = trends
+	return nil
+}
+
+func planStep(a *Agent, rc *RunContext) error {
+	if len(rc.Trends) == 0 {
+		return fmt.Errorf("plan step needs trends, run research first")
+	}
+	for i := 0; i < rc.PostCount; i++ {
+		plan, err := a.Plan(rc.Ctx, rc.Trends, a.nextPillar(rc.Plans), rc.Plans)
+		if err != nil {
+			return fmt.Errorf("planning failed: %w", err)
+		}
+		rc.Plans = append(rc.Plans, plan)
+		logger.GlobalBuffer.Info("Selected Topic %d: %s (%s)", i+1, plan.Topic, plan.Rationale)
+	}
+	return nil
+}
+
+// generateStep writes a first draft per plan and platform, or revises drafts
+// that failed a check in the previous round.
+func generateStep(a *Agent, rc *RunContext) error {
+	if rc.Variants == nil {
+		if len(rc.Plans) == 0 {
+			return fmt.Errorf("generate step needs a plan, run plan first")
+		}
+		for i, plan := range rc.Plans {
+			for _, platform := range a.platforms() {
+				for _, locale := range a.locales() {
+					rc.Variants = append(rc.Variants, &Variant{PlanIndex: i, Plan: plan, Platform: platform, Locale: locale})
+				}
+			}
+		}
+	}
+
+	for _, v := range rc.Variants {
+		if v.done {
+			continue
+		}
+
+		var err error
+		a.variant = v.label()
+		if v.Draft == "" {
+			v.Draft, err = a.Generate(rc.Ctx, v.Plan, v.Platform, v.Locale)
+		} else {
+			prev := v.Iterations[len(v.Iterations)-1]
+			feedback := models.Critique{Feedback: prev.Issue}
+			if prev.Critique != nil {
+				feedback = *prev.Critique
+				if prev.Issue != "" {
+					feedback.Feedback = prev.Issue + " " + feedback.Feedback
+				}
+			}
+			v.Draft, err = a.Revise(rc.Ctx, v.Plan, v.Draft, feedback, v.Platform, v.Locale)
+		}
+		a.variant = ""
+		if err != nil {
+			return err
+		}
+		v.Draft = a.attachSource(v.Draft, v.Plan)
+		v.Critique = nil
+		v.Blocked = false
+		v.Duplicate = false
+		v.rejected = false
+
+		if err := a.checkLength(v.Draft, v.Platform); err != nil {
+			logger.GlobalBuffer.Warn("[%s] Draft Iteration %d rejected: %v", v.label(), rc.Round+1, err)
+			v.Reject(err.Error())
+		}
+	}
+	return nil
+}
+
+// guardStep rejects drafts that touch an anti-topic or repeat a recent post.
+func guardStep(a *Agent, rc *RunContext) error {
+	var safe []*Variant
+	for _, v := range rc.Pending() {
+		rejection, err := a.CheckDraft(rc.Ctx, v.Draft, v.Platform)
+		if err != nil {
+			return err
+		}
+		if rejection != nil {
+			v.Blocked = true
+			v.Reject(fmt.Sprintf("The post touches the forbidden topic %q (%s). Remove it entirely.", rejection.AntiTopic, rejection.Reason))
+			continue
+		}
+		safe = append(safe, v)
+	}
+
+	drafts := make([]string, len(safe))
+	for i, v := range safe {
+		drafts[i] = v.Draft
+	}
+	for i, dup := range a.findDuplicates(drafts) {
+		if dup == nil {
+			continue
+		}
+		v := safe[i]
+		v.Duplicate = true
+		logger.GlobalBuffer.Warn("[%s] Draft Iteration %d is a near-duplicate of %s (similarity %.2f)", v.label(), rc.Round+1, dup.ID, dup.Similarity)
+		v.Reject(dup.issue())
+	}
+	return nil
+}
+
+// evaluateStep scores drafts and sends those below the brand threshold back for revision.
+func evaluateStep(a *Agent, rc *RunContext) error {
+	minScore := a.minScore()
+	for _, v := range rc.Pending() {
+		c, err := a.Evaluate(rc.Ctx, v.Plan, v.Draft, v.Platform, v.Locale)
+		if err != nil {
+			return err
+		}
+		v.Critique = &c
+		iteration := models.Iteration{Draft: v.Draft, Critique: &c}
+
+		logger.GlobalBuffer.Info("[%s] Draft Iteration %d (Score: %d/10)", v.label(), rc.Round+1, c.Score)
+		if v.Locale != "" && !sameLanguage(c.Language, v.Locale) {
+			iteration.Issue = fmt.Sprintf("The post is written in %s but must be written in %s.", c.Language, languageName(v.Locale))
+			logger.GlobalBuffer.Warn("[%s] %s", v.label(), iteration.Issue)
+			v.rejected = true
+		}
+		v.Iterations = append(v.Iterations, iteration)
+		if c.Score < minScore {
+			logger.GlobalBuffer.Warn("Feedback: %s", c.Feedback)
+			v.rejected = true
+		}
+	}
+	return nil
+}
+
+// publishStep posts approved variants, or schedules them when planning a batch.
+func publishStep(a *Agent, rc *RunContext) error {
+	switch rc.Mode {
+	case ModeSchedule:
+		return scheduleVariants(a, rc)
+	case ModeReview:
+		if err := scheduleVariants(a, rc); err != nil {
+			return err
+		}
+		if len(rc.Scheduled) == 0 {
+			return fmt.Errorf("failed to generate satisfactory content after %d attempts", maxDraftRounds)
+		}
+		return nil
+	}
+
+	var approved []*Variant
+	for _, v := range rc.Variants {
+		if v.Approved {
+			approved = append(approved, v)
+		} else {
+			logger.GlobalBuffer.Warn("No satisfactory %s variant after %d attempts, skipping platform", v.label(), maxDraftRounds)
+		}
+	}
+	if len(approved) == 0 {
+		return fmt.Errorf("failed to generate satisfactory content after %d attempts", maxDraftRounds)
+	}
+
+	logger.GlobalBuffer.Info("Publishing %d variants...", len(approved))
+	published := 0
+	for _, v := range approved {
+		post := &models.Post{
+			ID:         fmt.Sprintf("post-%d-%s", time.Now().Unix(), v.key()),
+			BrandID:    a.Brand.ID,
+			Topic:      v.Plan.Topic,
+			Content:    v.Draft,
+			SourceURL:  v.Plan.SourceURL(),
+			Pillar:     v.Plan.Pillar,
+			Platform:   v.Platform,
+			Locale:     v.Locale,
+			Status:     models.StatusApproved,
+			Critique:   v.Critique,
+			Iterations: v.Iterations,
+			CreatedAt:  time.Now(),
+
+			PromptVersions: a.promptVersions(),
+		}
+		if err := a.Social.Post(post); err != nil {
+			logger.GlobalBuffer.Error("Posting to %s failed: %v", v.label(), err)
+			post.Status = models.StatusFailed
+			post.UpdatedAt = time.Now()
+		} else {
+			published++
+		}
+		rc.Posts = append(rc.Posts, post)
+	}
+
+	logger.GlobalBuffer.Info("Saving to long-term memory...")
+	for _, post := range rc.Posts {
+		if err := a.Store.SavePost(*post); err != nil {
+			return fmt.Errorf("memory storage failed: %w", err)
+		}
+	}
+
+	if published == 0 {
+		return fmt.Errorf("posting failed on every platform")
+	}
+	return nil
+}
+
+// scheduleVariants saves each finished variant as a pending scheduled post, one
+// plan per day. Drafts that reached the critic but scored below the threshold
+// are kept as well; they wait for human review before publishing anyway.
+// In review mode only approved variants are kept and they are due right away.
+func scheduleVariants(a *Agent, rc *RunContext) error {
+	for _, v := range rc.Variants {
+		if v.Blocked {
+			logger.GlobalBuffer.Warn("Skipping %s post %d: every draft was rejected by the guardrails", v.label(), v.PlanIndex+1)
+			continue
+		}
+		if v.Duplicate {
+			logger.GlobalBuffer.Warn("Skipping %s post %d: every draft repeated a recent post", v.label(), v.PlanIndex+1)
+			continue
+		}
+		if !v.Approved && (rc.Mode == ModeReview || v.Critique == nil) {
+			logger.GlobalBuffer.Warn("Skipping %s post %d: no draft passed the checks", v.label(), v.PlanIndex+1)
+			continue
+		}
+
+		// Schedule them evenly over the next week (simplified logic)
+		scheduleTime := time.Now().Add(time.Duration((v.PlanIndex+1)*24) * time.Hour)
+		if rc.Mode == ModeReview {
+			scheduleTime = time.Now()
+		}
+		sp := models.ScheduledPost{
+			ID:          fmt.Sprintf("sp-%d-%d-%s", time.Now().Unix(), v.PlanIndex, v.key()),
+			BrandID:     a.Brand.ID,
+			Topic:       v.Plan.Topic,
+			Content:     v.Draft,
+			SourceURL:   v.Plan.SourceURL(),
+			Pillar:      v.Plan.Pillar,
+			Platform:    v.Platform,
+			Locale:      v.Locale,
+			Status:      models.StatusPending,
+			Critique:    v.Critique,
+			Iterations:  v.Iterations,
+			ScheduledAt: scheduleTime,
+			CreatedAt:   time.Now(),
+
+			PromptVersions: a.promptVersions(),
+		}
+
+		if err := a.Store.SaveScheduledPost(sp); err != nil {
+			logger.GlobalBuffer.Error("Warning: Failed to save scheduled post: %v", err)
+			continue
+		}
+		rc.Scheduled = append(rc.Scheduled, sp)
+		logger.GlobalBuffer.Info("✅ Scheduled %s post %d for %v", v.label(), v.PlanIndex+1, scheduleTime.Format(time.RFC822))
+	}
+	return nil
+}
+
+// rememberStep indexes the run's published posts in vector memory.
+func rememberStep(a *Agent, rc *RunContext) error {
+	for _, post := range rc.Posts {
+		if post.Status != models.StatusFailed {
+			a.remember(*post)
+		}
+	}
+	return nil
+}
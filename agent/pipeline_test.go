@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatePipeline(t *testing.T) {
+	for _, names := range [][]string{nil, DefaultPipeline, {StepResearch, StepPlan, StepGenerate}} {
+		if err := ValidatePipeline(names); err != nil {
+			t.Errorf("%v: %v", names, err)
+		}
+	}
+	for _, names := range [][]string{{"reasearch"}, {StepResearch, ""}, {StepPlan, "Publish"}} {
+		if err := ValidatePipeline(names); err == nil {
+			t.Errorf("%q accepted", names)
+		}
+	}
+}
+
+func TestPlanStepListsPlannedTopics(t *testing.T) {
+	a, llm := structuredAgent(t, tools.Script{Rules: []tools.ScriptRule{{
+		Match: `Past topics`,
+		Responses: []string{
+			`{"topic": "Go 1.23", "rationale": "r", "source_index": 0}`,
+			`{"topic": "Range over func", "rationale": "r", "source_index": 0}`,
+			`{"topic": "Iterators", "rationale": "r", "source_index": 0}`,
+		},
+	}}})
+	rc := &RunContext{Ctx: context.Background(), Trends: planTrends, PostCount: 3}
+	if err := planStep(a, rc); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := llm.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("%d requests, want 3", len(requests))
+	}
+	last := requests[2].Messages[0].Content
+	if !strings.Contains(last, "Past topics we covered: Go 1.23, Range over func") {
+		t.Errorf("third plan prompt does not list the topics planned before it:\n%s", last)
+	}
+}
+
+// TestPlanBatchWithCache checks that scheduling runs never reuse cached
+// responses, while previews may.
+func TestPlanBatchWithCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := tools.OpenCache(filepath.Join(dir, "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	scripted, err := tools.LoadScriptedLLM("../config/fake/llm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	search, err := tools.LoadFixtureSearch("../config/fake/trends.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewFileStore(dir)
+	brand := models.BrandProfile{ID: "brand", Name: "Brand", Industry: "software", Platforms: []string{models.PlatformLinkedIn}}
+	a := NewAgent(brand, search, tools.NewCachedLLM(cache, "fake", scripted.Model, scripted), nil, store, nil, nil, nil)
+
+	if err := a.PlanBatch(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	scheduled, _ := store.GetScheduledPosts(brand.ID)
+	topics := make(map[string]bool)
+	for _, sp := range scheduled {
+		topics[sp.Topic] = true
+	}
+	if len(scheduled) != 3 || len(topics) != 3 {
+		t.Fatalf("scheduled %d posts on %d topics, want 3 distinct", len(scheduled), len(topics))
+	}
+
+	calls := len(scripted.Requests())
+	if err := a.PlanBatch(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(scripted.Requests()) - calls; got != calls {
+		t.Errorf("repeated batch made %d LLM calls, want all %d to reach the provider", got, calls)
+	}
+
+	calls = len(scripted.Requests())
+	a.Preview(tools.WithCache(context.Background()))
+	if got := len(scripted.Requests()); got != calls {
+		t.Errorf("preview made %d LLM calls, want them served from the cache", got-calls)
+	}
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools/logger"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// PlatformSpec describes the length and style rules for one platform variant.
+type PlatformSpec struct {
+	Name     string
+	MaxChars int
+	Style    string
+}
+
+// PlatformSpecs lists every platform the agent knows how to write for.
+var PlatformSpecs = map[string]PlatformSpec{
+	models.PlatformX: {
+		Name:     "X (Twitter)",
+		MaxChars: 280,
+		Style:    "A single punchy tweet. Lead with the hook, no preamble, at most 2 hashtags.",
+	},
+	models.PlatformLinkedIn: {
+		Name:     "LinkedIn",
+		MaxChars: 3000,
+		Style:    "A professional post of roughly 150 words in short paragraphs, ending with a question or call to action and 3-5 hashtags.",
+	},
+	models.PlatformThreads: {
+		Name:     "Threads",
+		MaxChars: 500,
+		Style:    "Conversational and casual, one or two short paragraphs, at most 3 hashtags.",
+	},
+	models.PlatformInstagram: {
+		Name:     "Instagram",
+		MaxChars: 2200,
+		Style:    "An image caption: a strong first line, short lines with emojis where natural, and up to 10 hashtags at the end.",
+	},
+}
+
+// xThreadSpec replaces the X spec for brands that publish long-form threads.
+var xThreadSpec = PlatformSpec{
+	Name:     "X (Twitter) thread",
+	MaxChars: 1500,
+	Style:    "A long-form thread that opens with a strong hook. Use short, self-contained sentences so it splits cleanly into tweets, do not number the tweets yourself, and put at most 2 hashtags at the very end.",
+}
+
+// DefaultPlatforms is used when neither the brand nor the social client names any platform.
+var DefaultPlatforms = []string{models.PlatformX, models.PlatformLinkedIn}
+
+// platformLister is implemented by social clients that know which platforms they can post to.
+type platformLister interface {
+	Platforms() []string
+}
+
+// platforms returns the platforms this run should produce variants for.
+func (a *Agent) platforms() []string {
+	candidates := a.Brand.Platforms
+	if len(candidates) == 0 {
+		if lister, ok := a.Social.(platformLister); ok {
+			candidates = lister.Platforms()
+		}
+	}
+
+	var result []string
+	for _, p := range candidates {
+		if _, ok := PlatformSpecs[p]; ok {
+			result = append(result, p)
+		} else if len(a.Brand.Platforms) > 0 {
+			logger.GlobalBuffer.Warn("Skipping unsupported platform %q for brand %s", p, a.Brand.ID)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, DefaultPlatforms...)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// specFor returns the writing rules for a platform, honouring brand options.
+func (a *Agent) specFor(platform string) PlatformSpec {
+	if platform == models.PlatformX && a.Brand.XThreads {
+		return xThreadSpec
+	}
+	return PlatformSpecs[platform]
+}
+
+// checkLength rejects drafts that do not fit on the target platform.
+func (a *Agent) checkLength(content, platform string) error {
+	spec := a.specFor(platform)
+	if n := utf8.RuneCountInString(content); spec.MaxChars > 0 && n > spec.MaxChars {
+		return fmt.Errorf("draft is %d characters, %s allows at most %d", n, spec.Name, spec.MaxChars)
+	}
+	return nil
+}
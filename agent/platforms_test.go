@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"reflect"
+	"testing"
+)
+
+// accountClient stands in for a real social account.
+type accountClient struct{}
+
+func (accountClient) Post(*models.Post) error { return nil }
+
+func TestPlatformsSkipStubClients(t *testing.T) {
+	social := tools.NewMultiSocialClient()
+	social.AddClient("linkedin", accountClient{})
+	social.AddClient("twitter:es-MX", accountClient{})
+	social.AddClient("instagram", tools.NewInstagramClient())
+	social.AddClient("threads", tools.NewThreadsClient())
+	social.AddClient("tiktok", tools.NewTikTokClient())
+
+	a := &Agent{Social: social}
+	if got, want := a.platforms(), []string{models.PlatformLinkedIn, models.PlatformX}; !reflect.DeepEqual(got, want) {
+		t.Errorf("platforms = %v, want only those with an account %v", got, want)
+	}
+
+	a.Brand.Platforms = []string{models.PlatformInstagram}
+	if got := a.platforms(); !reflect.DeepEqual(got, []string{models.PlatformInstagram}) {
+		t.Errorf("platforms = %v, want the brand's own list", got)
+	}
+
+	a.Brand.Platforms = nil
+	a.Social = &tools.MultiSocialClient{Clients: map[string]tools.SocialClient{"threads": tools.NewThreadsClient()}}
+	if got := a.platforms(); !reflect.DeepEqual(got, []string{models.PlatformLinkedIn, models.PlatformX}) {
+		t.Errorf("platforms = %v, want the defaults without any account", got)
+	}
+}
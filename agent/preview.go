@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools/logger"
+	"context"
+)
+
+// Preview performs a dry run of the brand's pipeline. It researches, plans,
+// drafts and evaluates exactly like Run, but nothing is posted and neither
+// history, vector memory nor guardrail rejections are written. The report is
+// returned even when the run stops early; err says why.
+func (a *Agent) Preview(ctx context.Context) (*models.RunReport, error) {
+	logger.GlobalBuffer.Info("🔍 Previewing agent cycle for brand: %s", a.Brand.Name)
+
+	a.beginRun(RunKindPreview)
+	defer func() {
+		a.rejections = nil
+		a.trace = nil
+	}()
+
+	rc := &RunContext{Mode: ModePublish, PostCount: 1, DryRun: true}
+	err := a.runPipeline(ctx, rc)
+
+	report := &models.RunReport{
+		BrandID:    a.Brand.ID,
+		RunID:      a.trace.ID,
+		RunAt:      a.runAt,
+		Trends:     rc.Trends,
+		Rejections: a.rejections,
+		Variants:   []models.VariantReport{},
+	}
+	if len(rc.Plans) > 0 {
+		report.Plan = &rc.Plans[0]
+	}
+	for _, v := range rc.Variants {
+		report.Variants = append(report.Variants, models.VariantReport{
+			Platform:   v.Platform,
+			Locale:     v.Locale,
+			Content:    v.Draft,
+			Approved:   v.Approved,
+			Blocked:    v.Blocked,
+			Duplicate:  v.Duplicate,
+			Critique:   v.Critique,
+			Iterations: v.Iterations,
+		})
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report, err
+}
@@ -0,0 +1,386 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"content-creator-agent/tools/logger"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Prompt template names.
+const (
+	PromptPlan       = "plan"
+	PromptGenerate   = "generate"
+	PromptRevise     = "revise"
+	PromptEvaluate   = "evaluate"
+	PromptTrendGuard = "trend_guard"
+	PromptDraftGuard = "draft_guard"
+)
+
+// PromptTemplate is one version of a prompt. Text is a text/template that
+// defines a "system" and a "user" template.
+type PromptTemplate struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"` // "v1", "v2"… for built-ins, "brand-<hash>" for brand overrides
+	Text     string `json:"text"`
+	Override bool   `json:"override,omitempty"` // Written by the brand rather than built in
+}
+
+// Label identifies the template in traces, e.g. "generate@v1".
+func (p PromptTemplate) Label() string {
+	return p.Name + "@" + p.Version
+}
+
+// Built-in templates live in prompts/<name>.v<N>.tmpl. A new revision of a
+// prompt is added as a new file; the highest version is used unless a brand
+// pins an older one.
+//
+//go:embed prompts/*.tmpl
+var promptFiles embed.FS
+
+var promptFileRe = regexp.MustCompile(`^([a-z_]+)\.v(\d+)\.tmpl$`)
+
+// pinnedVersionRe matches brand prompt settings that select a built-in version instead of overriding it.
+var pinnedVersionRe = regexp.MustCompile(`^v\d+$`)
+
+var promptFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// planPrompt is the data of the plan template.
+type planPrompt struct {
+	Brand      models.BrandProfile
+	Trends     []models.Trend
+	PastTopics []string
+	Memories   []promptMemory // Semantic memories of past successes
+	Pillar     *models.Pillar // Pillar the topic must fit, if any
+}
+
+type promptMemory struct {
+	Topic      string
+	Engagement float64
+}
+
+// draftPrompt is the data of the generate and revise templates.
+type draftPrompt struct {
+	Brand       models.BrandProfile
+	Plan        models.ContentPlan
+	Platform    PlatformSpec
+	Locale      string          // e.g. "es-MX"; empty for brands without locales
+	Language    string          // The locale as described to the model, e.g. "Spanish (es-MX)"
+	Budget      int             // Characters the post may use
+	SourceBrief string          // Source article section
+	Examples    string          // Best-performing past posts section (generate only)
+	LocaleBrief string          // Language and market section
+	Draft       string          // Draft being revised, without its source link (revise only)
+	Critique    models.Critique // Feedback on that draft (revise only)
+}
+
+// evaluatePrompt is the data of the evaluate template.
+type evaluatePrompt struct {
+	Brand       models.BrandProfile
+	Plan        models.ContentPlan
+	Platform    PlatformSpec
+	Locale      string
+	Language    string
+	SourceBrief string
+	Content     string
+}
+
+// guardPrompt is the data of the trend_guard and draft_guard templates.
+type guardPrompt struct {
+	Brand    models.BrandProfile
+	Trends   []models.Trend // Trends to classify (trend_guard only)
+	Draft    string         // Draft to classify (draft_guard only)
+	Platform string         // Platform of the draft (draft_guard only)
+}
+
+// promptData holds empty data for every prompt, which overrides are validated against.
+var promptData = map[string]interface{}{
+	PromptPlan:       planPrompt{},
+	PromptGenerate:   draftPrompt{},
+	PromptRevise:     draftPrompt{},
+	PromptEvaluate:   evaluatePrompt{},
+	PromptTrendGuard: guardPrompt{},
+	PromptDraftGuard: guardPrompt{},
+}
+
+// builtinPrompts maps every prompt name to its built-in versions, oldest first.
+var builtinPrompts = loadBuiltinPrompts()
+
+// parsedPrompts caches parsed templates by their text.
+var parsedPrompts sync.Map
+
+func loadBuiltinPrompts() map[string][]PromptTemplate {
+	files, err := fs.Glob(promptFiles, "prompts/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	prompts := make(map[string][]PromptTemplate)
+	for _, file := range files {
+		m := promptFileRe.FindStringSubmatch(strings.TrimPrefix(file, "prompts/"))
+		if m == nil {
+			panic(fmt.Sprintf("prompt file %s is not named <name>.v<N>.tmpl", file))
+		}
+		text, err := promptFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		data, ok := promptData[m[1]]
+		if !ok {
+			panic(fmt.Sprintf("prompt file %s is for an unknown prompt", file))
+		}
+		if err := checkPrompt(string(text), data); err != nil {
+			panic(fmt.Sprintf("prompt file %s: %v", file, err))
+		}
+		prompts[m[1]] = append(prompts[m[1]], PromptTemplate{Name: m[1], Version: "v" + m[2], Text: string(text)})
+	}
+	for name := range promptData {
+		list := prompts[name]
+		if len(list) == 0 {
+			panic(fmt.Sprintf("no built-in template for prompt %s", name))
+		}
+		sort.Slice(list, func(i, j int) bool { return versionNumber(list[i].Version) < versionNumber(list[j].Version) })
+	}
+	return prompts
+}
+
+// versionNumber is the number of a built-in version, e.g. 2 for "v2".
+func versionNumber(version string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	return n
+}
+
+// PromptNames lists the prompts the agent renders, sorted.
+func PromptNames() []string {
+	var names []string
+	for name := range promptData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinPrompts returns every built-in template version, by name and then version.
+func BuiltinPrompts() []PromptTemplate {
+	var all []PromptTemplate
+	for _, name := range PromptNames() {
+		all = append(all, builtinPrompts[name]...)
+	}
+	return all
+}
+
+// latestPrompt returns the newest built-in version of a prompt.
+func latestPrompt(name string) PromptTemplate {
+	versions := builtinPrompts[name]
+	return versions[len(versions)-1]
+}
+
+// PromptFor returns the template a brand renders for a prompt: its override,
+// the built-in version it pins, or the latest built-in version.
+func PromptFor(brand models.BrandProfile, name string) (PromptTemplate, error) {
+	if _, ok := promptData[name]; !ok {
+		return PromptTemplate{}, fmt.Errorf("unknown prompt %q", name)
+	}
+	text := strings.TrimSpace(brand.Prompts[name])
+	if text == "" {
+		return latestPrompt(name), nil
+	}
+	if pinnedVersionRe.MatchString(text) {
+		for _, p := range builtinPrompts[name] {
+			if p.Version == text {
+				return p, nil
+			}
+		}
+		return PromptTemplate{}, fmt.Errorf("prompt %s has no built-in version %s", name, text)
+	}
+	sum := sha256.Sum256([]byte(brand.Prompts[name]))
+	return PromptTemplate{
+		Name:     name,
+		Version:  "brand-" + hex.EncodeToString(sum[:4]),
+		Text:     brand.Prompts[name],
+		Override: true,
+	}, nil
+}
+
+// BrandPrompts returns the template the brand renders for every prompt.
+func BrandPrompts(brand models.BrandProfile) ([]PromptTemplate, error) {
+	var prompts []PromptTemplate
+	for _, name := range PromptNames() {
+		p, err := PromptFor(brand, name)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, nil
+}
+
+// ValidatePrompt checks a brand setting for a prompt: either a built-in
+// version such as "v1", or a template that parses, defines "system" and
+// "user", and renders against empty data.
+func ValidatePrompt(name, text string) error {
+	data, ok := promptData[name]
+	if !ok {
+		return fmt.Errorf("unknown prompt %q", name)
+	}
+	if pinnedVersionRe.MatchString(strings.TrimSpace(text)) {
+		_, err := PromptFor(models.BrandProfile{Prompts: map[string]string{name: text}}, name)
+		return err
+	}
+	return checkPrompt(text, data)
+}
+
+func checkPrompt(text string, data interface{}) error {
+	t, err := parsePrompt(text)
+	if err != nil {
+		return err
+	}
+	_, err = executePrompt(t, data)
+	return err
+}
+
+func parsePrompt(text string) (*template.Template, error) {
+	if t, ok := parsedPrompts.Load(text); ok {
+		return t.(*template.Template), nil
+	}
+	t, err := template.New("prompt").Funcs(promptFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range []string{"system", "user"} {
+		if t.Lookup(part) == nil {
+			return nil, fmt.Errorf("template does not define %q", part)
+		}
+	}
+	parsedPrompts.Store(text, t)
+	return t, nil
+}
+
+func executePrompt(t *template.Template, data interface{}) (tools.LLMRequest, error) {
+	var system, user strings.Builder
+	if err := t.ExecuteTemplate(&system, "system", data); err != nil {
+		return tools.LLMRequest{}, err
+	}
+	if err := t.ExecuteTemplate(&user, "user", data); err != nil {
+		return tools.LLMRequest{}, err
+	}
+	return tools.Prompt(system.String(), user.String()), nil
+}
+
+func renderPrompt(p PromptTemplate, data interface{}) (tools.LLMRequest, error) {
+	t, err := parsePrompt(p.Text)
+	if err != nil {
+		return tools.LLMRequest{}, err
+	}
+	return executePrompt(t, data)
+}
+
+// render builds the request for a prompt from the brand's template and
+// records the version used on the run trace. An override that fails to render
+// is logged and replaced by the latest built-in version.
+func (a *Agent) render(name string, data interface{}) (tools.LLMRequest, PromptTemplate, error) {
+	p, err := PromptFor(a.Brand, name)
+	if err == nil {
+		var req tools.LLMRequest
+		if req, err = renderPrompt(p, data); err == nil {
+			a.notePrompt(p)
+			return req, p, nil
+		}
+	}
+
+	builtin := latestPrompt(name)
+	if p.Version == builtin.Version {
+		return tools.LLMRequest{}, p, fmt.Errorf("prompt %s: %w", p.Label(), err)
+	}
+	logger.GlobalBuffer.Warn("Prompt %s of brand %s failed (%v), using %s", name, a.Brand.ID, err, builtin.Label())
+	req, err := renderPrompt(builtin, data)
+	if err != nil {
+		return tools.LLMRequest{}, builtin, fmt.Errorf("prompt %s: %w", builtin.Label(), err)
+	}
+	a.notePrompt(builtin)
+	return req, builtin, nil
+}
+
+// notePrompt records the version of a rendered prompt on the run trace.
+func (a *Agent) notePrompt(p PromptTemplate) {
+	if a.trace == nil {
+		return
+	}
+	if a.trace.PromptVersions == nil {
+		a.trace.PromptVersions = make(map[string]string)
+	}
+	a.trace.PromptVersions[p.Name] = p.Version
+}
+
+// promptVersions returns a copy of the prompt versions rendered so far in the
+// current run, for the posts it produces.
+func (a *Agent) promptVersions() map[string]string {
+	if a.trace == nil || len(a.trace.PromptVersions) == 0 {
+		return nil
+	}
+	versions := make(map[string]string, len(a.trace.PromptVersions))
+	for name, version := range a.trace.PromptVersions {
+		versions[name] = version
+	}
+	return versions
+}
+
+// PromptPerformance groups the brand's posts by the version of each prompt
+// they were written with, so prompt revisions can be compared. Failed posts
+// and posts that predate prompt versioning are left out. An empty prompt
+// reports every prompt.
+func PromptPerformance(history []models.Post, prompt string) []models.PromptPerformance {
+	results := []models.PromptPerformance{}
+	index := make(map[string]int)
+	scored := make(map[string]int)
+	for _, post := range history {
+		if post.Status == models.StatusFailed {
+			continue
+		}
+		for name, version := range post.PromptVersions {
+			if prompt != "" && name != prompt {
+				continue
+			}
+			key := name + "@" + version
+			i, ok := index[key]
+			if !ok {
+				i = len(results)
+				index[key] = i
+				results = append(results, models.PromptPerformance{Prompt: name, Version: version})
+			}
+			r := &results[i]
+			r.Posts++
+			r.Likes += post.Analytics.Likes
+			r.Shares += post.Analytics.Shares
+			r.Comments += post.Analytics.Comments
+			if post.Critique != nil && post.Critique.Score > 0 {
+				r.AvgScore += float64(post.Critique.Score)
+				scored[key]++
+			}
+		}
+	}
+
+	for i := range results {
+		if n := scored[results[i].Prompt+"@"+results[i].Version]; n > 0 {
+			results[i].AvgScore /= float64(n)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Prompt != results[j].Prompt {
+			return results[i].Prompt < results[j].Prompt
+		}
+		return results[i].Version < results[j].Version
+	})
+	return results
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOverride = `{{define "system"}}You pick topics.{{end}}{{define "user"}}Trends: {{range .Trends}}{{.Title}} {{end}}{{end}}`
+
+func TestPromptFor(t *testing.T) {
+	latest := latestPrompt(PromptPlan)
+	tests := []struct {
+		name    string
+		setting string
+		version string
+		wantErr bool
+	}{
+		{"default", "", latest.Version, false},
+		{"blank", "  ", latest.Version, false},
+		{"pinned", "v1", "v1", false},
+		{"pinned with spaces", " v1\n", "v1", false},
+		{"unknown pinned version", "v99", "", true},
+		{"override", testOverride, "brand-", false},
+	}
+	for _, tt := range tests {
+		brand := models.BrandProfile{Prompts: map[string]string{PromptPlan: tt.setting}}
+		p, err := PromptFor(brand, PromptPlan)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !strings.HasPrefix(p.Version, tt.version) {
+			t.Errorf("%s: version = %s, want %s", tt.name, p.Version, tt.version)
+		}
+	}
+
+	override, _ := PromptFor(models.BrandProfile{Prompts: map[string]string{PromptPlan: testOverride}}, PromptPlan)
+	if !override.Override || override.Text != testOverride || override.Label() != "plan@"+override.Version {
+		t.Errorf("override = %+v", override)
+	}
+	again, _ := PromptFor(models.BrandProfile{Prompts: map[string]string{PromptPlan: testOverride}}, PromptPlan)
+	if again.Version != override.Version {
+		t.Error("the same override got two versions")
+	}
+	if _, err := PromptFor(models.BrandProfile{}, "unknown"); err == nil {
+		t.Error("unknown prompt accepted")
+	}
+}
+
+func TestValidatePrompt(t *testing.T) {
+	tests := []struct {
+		name, prompt, text string
+		ok                 bool
+	}{
+		{"override", PromptPlan, testOverride, true},
+		{"pinned", PromptPlan, "v1", true},
+		{"unknown pinned version", PromptPlan, "v99", false},
+		{"unknown prompt", "summary", testOverride, false},
+		{"missing user", PromptPlan, `{{define "system"}}x{{end}}`, false},
+		{"missing system", PromptPlan, `{{define "user"}}x{{end}}`, false},
+		{"no templates", PromptPlan, "Pick a topic", false},
+		{"syntax error", PromptPlan, `{{define "system"}}x{{end}}{{define "user"}}{{.Trends{{end}}`, false},
+		{"unknown field", PromptPlan, `{{define "system"}}x{{end}}{{define "user"}}{{.Audience}}{{end}}`, false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePrompt(tt.prompt, tt.text); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestRenderFallsBackToBuiltin(t *testing.T) {
+	// Valid against empty data, but fails once there are trends to index.
+	broken := `{{define "system"}}x{{end}}{{define "user"}}{{if .Trends}}{{index .Trends 5}}{{end}}{{end}}`
+	if err := ValidatePrompt(PromptPlan, broken); err != nil {
+		t.Fatalf("override rejected up front: %v", err)
+	}
+
+	a := &Agent{Brand: models.BrandProfile{ID: "brand", Prompts: map[string]string{PromptPlan: broken}}}
+	data := planPrompt{Trends: []models.Trend{{Title: "Go 1.23 released"}}}
+	req, p, err := a.render(PromptPlan, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != latestPrompt(PromptPlan) || !strings.Contains(req.Messages[0].Content, "Go 1.23 released") {
+		t.Errorf("rendered %s, want the latest built-in version", p.Label())
+	}
+
+	a.Brand.Prompts[PromptPlan] = testOverride
+	req, p, err = a.render(PromptPlan, data)
+	if err != nil || !p.Override || req.System != "You pick topics." {
+		t.Errorf("working override: %s, %q, %v", p.Label(), req.System, err)
+	}
+}
+
+func TestPromptPerformance(t *testing.T) {
+	post := func(status models.PostStatus, versions map[string]string, likes, score int) models.Post {
+		p := models.Post{Status: status, PromptVersions: versions, Analytics: models.Analytics{Likes: likes, Shares: 1}}
+		if score > 0 {
+			p.Critique = &models.Critique{Score: score}
+		}
+		return p
+	}
+	history := []models.Post{
+		post(models.StatusPublished, map[string]string{"generate": "v2", "plan": "v1"}, 10, 8),
+		post(models.StatusPublished, map[string]string{"generate": "v1", "plan": "v1"}, 4, 6),
+		post(models.StatusPublished, map[string]string{"generate": "v2"}, 20, 0),
+		post(models.StatusPublished, map[string]string{"generate": "v2"}, 30, 7),
+		post(models.StatusFailed, map[string]string{"generate": "v2"}, 100, 1),
+		post(models.StatusPublished, nil, 50, 9),
+	}
+
+	want := []models.PromptPerformance{
+		{Prompt: "generate", Version: "v1", Posts: 1, Likes: 4, Shares: 1, AvgScore: 6},
+		{Prompt: "generate", Version: "v2", Posts: 3, Likes: 60, Shares: 3, AvgScore: 7.5},
+		{Prompt: "plan", Version: "v1", Posts: 2, Likes: 14, Shares: 2, AvgScore: 7},
+	}
+	if got := PromptPerformance(history, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("all prompts:\n got %+v\nwant %+v", got, want)
+	}
+	if got := PromptPerformance(history, "plan"); !reflect.DeepEqual(got, want[2:]) {
+		t.Errorf("plan only: %+v", got)
+	}
+	if got := PromptPerformance(nil, ""); got == nil || len(got) != 0 {
+		t.Errorf("empty history = %#v, want an empty list", got)
+	}
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Weights of the signals used to rank recalled memories. They sum to 1.
+const (
+	similarityWeight = 0.6
+	engagementWeight = 0.25
+	recencyWeight    = 0.15
+)
+
+// recencyHalfLife is the age at which a memory's recency signal halves.
+const recencyHalfLife = 30 * 24 * time.Hour
+
+// recallCandidates is how many nearest neighbours are fetched per requested memory before re-ranking.
+const recallCandidates = 4
+
+// fewShotExamples is how many top-performing posts are shown to the writer.
+const fewShotExamples = 3
+
+// recalledMemory is a vector match re-ranked by engagement and recency.
+type recalledMemory struct {
+	memory.SearchResult
+	Engagement float64
+	Rank       float64
+}
+
+// engagementScore is the simple performance score stored in vector metadata.
+func engagementScore(m models.Analytics) int {
+	return m.Likes + (m.Shares * 2)
+}
+
+// recall returns the brand's k most useful memories for query: semantically
+// close, well received and recent. Failures yield no memories.
+func (a *Agent) recall(query string, k int) []recalledMemory {
+	if a.Embedding == nil || a.Vector == nil {
+		return nil
+	}
+	queryEmbeds, model, err := a.embed(tools.TaskRetrievalQuery, query)
+	if err != nil {
+		return nil
+	}
+	matches, err := a.Vector.Query(queryEmbeds[0], k*recallCandidates)
+	if err != nil {
+		return nil
+	}
+
+	var memories []recalledMemory
+	maxEngagement := 0.0
+	for _, m := range matches {
+		if brand, ok := m.Metadata["brand"].(string); ok && brand != a.Brand.ID {
+			continue
+		}
+		if !sameEmbedding(m, model) {
+			continue
+		}
+		e := metadataNumber(m.Metadata["score"])
+		if e > maxEngagement {
+			maxEngagement = e
+		}
+		memories = append(memories, recalledMemory{SearchResult: m, Engagement: e})
+	}
+
+	for i := range memories {
+		m := &memories[i]
+		engagement := 0.0
+		if maxEngagement > 0 {
+			engagement = m.Engagement / maxEngagement
+		}
+		recency := 0.5 // Unknown age counts as middling
+		if ts, ok := m.Metadata["created_at"].(string); ok {
+			if createdAt, err := time.Parse(time.RFC3339, ts); err == nil {
+				recency = math.Pow(0.5, float64(time.Since(createdAt))/float64(recencyHalfLife))
+			}
+		}
+		m.Rank = similarityWeight*float64(m.Score) + engagementWeight*engagement + recencyWeight*recency
+	}
+
+	sort.SliceStable(memories, func(i, j int) bool { return memories[i].Rank > memories[j].Rank })
+	if len(memories) > k {
+		memories = memories[:k]
+	}
+	return memories
+}
+
+// recallQuery describes what the planner is looking for, based on the brand and the current trends.
+func (a *Agent) recallQuery(trends []models.Trend) string {
+	var titles []string
+	for i, t := range trends {
+		if i >= 3 {
+			break
+		}
+		titles = append(titles, t.Title)
+	}
+	query := fmt.Sprintf("%s posts for %s in %s", a.Brand.Name, a.Brand.TargetAudience, a.Brand.Industry)
+	if len(a.Brand.Topics) > 0 {
+		query += " about " + strings.Join(a.Brand.Topics, ", ")
+	}
+	if len(titles) > 0 {
+		query += ". Current trends: " + strings.Join(titles, "; ")
+	}
+	return query
+}
+
+// topPosts returns the brand's best-performing published posts in the given
+// locale, preferring the given platform. Posts without any engagement are ignored.
+func (a *Agent) topPosts(platform, locale string, n int) []models.Post {
+	history, err := a.Store.GetHistory(a.Brand.ID)
+	if err != nil {
+		return nil
+	}
+
+	var posts []models.Post
+	for _, p := range history {
+		if p.Status == models.StatusPublished && p.Locale == locale && engagementScore(p.Analytics) > 0 {
+			posts = append(posts, p)
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		pi, pj := posts[i].Platform == platform, posts[j].Platform == platform
+		if pi != pj {
+			return pi
+		}
+		return engagementScore(posts[i].Analytics) > engagementScore(posts[j].Analytics)
+	})
+	if len(posts) > n {
+		posts = posts[:n]
+	}
+	return posts
+}
+
+// fewShotBrief lists the brand's top posts as style examples for the writer.
+func (a *Agent) fewShotBrief(platform, locale string) string {
+	posts := a.topPosts(platform, locale, fewShotExamples)
+	if len(posts) == 0 {
+		return ""
+	}
+	var examples []string
+	for i, p := range posts {
+		content := p.Content
+		if p.SourceURL != "" {
+			content = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "Source: "+p.SourceURL))
+		}
+		examples = append(examples, fmt.Sprintf("Example %d (%s, %d likes, %d shares):\n%s", i+1, p.Platform, p.Analytics.Likes, p.Analytics.Shares, content))
+	}
+	return "\nOur best-performing past posts. Match their tone, structure and hooks, not their topics:\n" + strings.Join(examples, "\n\n") + "\n"
+}
+
+// metadataNumber reads a numeric metadata value, which is an int before the
+// vector store is persisted and a float64 after it is reloaded.
+func metadataNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// sourceBrief describes the plan's source article for prompts and instructs the
+// model to stay within what the source supports.
+func sourceBrief(plan models.ContentPlan) string {
+	if plan.Source == nil {
+		return ""
+	}
+	return fmt.Sprintf(`Source article (stay grounded in it; do not add facts, numbers or quotes it does not support):
+Title: %s
+Summary: %s
+URL: %s
+`, plan.Source.Title, plan.Source.Snippet, plan.Source.URL)
+}
+
+// sourceLink returns the line appended to posts of brands that include source links.
+func (a *Agent) sourceLink(plan models.ContentPlan) string {
+	if !a.Brand.IncludeSourceLink || plan.SourceURL() == "" {
+		return ""
+	}
+	return "Source: " + plan.SourceURL()
+}
+
+// attachSource appends the source link when the brand asks for it and the draft
+// does not already contain the URL.
+func (a *Agent) attachSource(draft string, plan models.ContentPlan) string {
+	link := a.sourceLink(plan)
+	if link == "" || strings.Contains(draft, plan.SourceURL()) {
+		return draft
+	}
+	return strings.TrimSpace(draft) + "\n\n" + link
+}
+
+// stripSource removes the appended source link so revisions work on the text alone.
+func (a *Agent) stripSource(draft string, plan models.ContentPlan) string {
+	link := a.sourceLink(plan)
+	if link == "" {
+		return draft
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(draft), link))
+}
+
+// textBudget is the number of characters the model may use, leaving room for an
+// appended source link.
+func (a *Agent) textBudget(plan models.ContentPlan, platform string) int {
+	budget := a.specFor(platform).MaxChars
+	if link := a.sourceLink(plan); link != "" {
+		budget -= utf8.RuneCountInString(link) + 2
+	}
+	return budget
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"content-creator-agent/tools"
+	"content-creator-agent/tools/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxStructuredAttempts bounds how often a malformed JSON response is retried.
+const maxStructuredAttempts = 3
+
+var trailingCommaRe = regexp.MustCompile(`,\s*([}\]])`)
+
+// generateJSON renders the named prompt, asks the LLM for a JSON object and
+// decodes it into T. Common formatting mistakes are repaired locally; responses that still fail to
+// decode or validate are sent back to the model together with the error, as
+// a follow-up turn of the same conversation.
+func generateJSON[T any](ctx context.Context, a *Agent, prompt string, data interface{}, validate func(*T) error) (T, error) {
+	req, tmpl, err := a.render(prompt, data)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	req.JSON = true
+	var lastErr error
+	for attempt := 1; attempt <= maxStructuredAttempts; attempt++ {
+		var out T
+		res, err := a.complete(ctx, tmpl, req)
+		if err != nil {
+			return out, err
+		}
+		response := res.Text
+
+		if err := json.Unmarshal([]byte(repairJSON(response)), &out); err != nil {
+			lastErr = fmt.Errorf("invalid JSON: %w", err)
+		} else if err := validate(&out); err != nil {
+			lastErr = err
+		} else {
+			return out, nil
+		}
+
+		logger.GlobalBuffer.Warn("Structured response attempt %d rejected: %v", attempt, lastErr)
+		req.Messages = append(req.Messages,
+			tools.LLMMessage{Role: tools.RoleAssistant, Content: response},
+			tools.LLMMessage{Role: tools.RoleUser, Content: fmt.Sprintf("Your previous response was rejected: %v\n\nReply again with ONLY the corrected JSON object.", lastErr)},
+		)
+	}
+
+	var zero T
+	return zero, fmt.Errorf("no valid structured response after %d attempts: %w", maxStructuredAttempts, lastErr)
+}
+
+// repairJSON strips markdown fences and surrounding prose from an LLM response
+// and removes trailing commas, leaving the outermost JSON object.
+func repairJSON(response string) string {
+	s := strings.TrimSpace(response)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimSuffix(s, "```")
+
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start >= 0 && end > start {
+		s = s[start : end+1]
+	}
+	return trailingCommaRe.ReplaceAllString(s, "$1")
+}
+
+// checkScore validates a single rubric dimension.
+func checkScore(name string, v int) error {
+	if v < 1 || v > 10 {
+		return fmt.Errorf("%s score %d is outside 1-10", name, v)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"clean", `{"a": 1}`, `{"a": 1}`},
+		{"json fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"bare fence", "```\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"prose", "Sure! Here it is: {\"a\": 1} Hope that helps.", `{"a": 1}`},
+		{"trailing comma", `{"a": 1,}`, `{"a": 1}`},
+		{"trailing comma in array", "{\"a\": [1, 2,\n]}", `{"a": [1, 2]}`},
+		{"nested trailing commas", "```json\n{\"a\": {\"b\": 1, }, }\n```", `{"a": {"b": 1}}`},
+		{"comma inside string kept", `{"a": "x, y"}`, `{"a": "x, y"}`},
+		{"no object", "no json here", "no json here"},
+	}
+	for _, tt := range tests {
+		if got := repairJSON(tt.in); got != tt.want {
+			t.Errorf("%s: repairJSON(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// structuredAgent returns an agent that plans with a scripted LLM.
+func structuredAgent(t *testing.T, script tools.Script) (*Agent, *tools.ScriptedLLM) {
+	t.Helper()
+	llm, err := tools.NewScriptedLLM(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAgent(models.BrandProfile{ID: "brand", Industry: "software"}, nil, llm, nil, memory.NewFileStore(t.TempDir()), nil, nil, nil)
+	return a, llm
+}
+
+var planTrends = []models.Trend{{Title: "Go 1.23 released", Snippet: "Range over func"}}
+
+func TestGenerateJSONCorrectsOnRetry(t *testing.T) {
+	invalid := "```json\n{\"topic\": \"\", \"rationale\": \"x\", \"source_index\": 0,}\n```"
+	a, llm := structuredAgent(t, tools.Script{
+		Rules: []tools.ScriptRule{{
+			Match:     `previous response was rejected`,
+			Responses: []string{`{"topic": "Go 1.23", "rationale": "New release", "source_index": 0}`},
+		}},
+		Default: invalid,
+	})
+
+	plan, err := a.Plan(context.Background(), planTrends, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Topic != "Go 1.23" || plan.SourceTrend != planTrends[0].Title {
+		t.Errorf("plan = %+v", plan)
+	}
+
+	requests := llm.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("%d requests, want one retry", len(requests))
+	}
+	retry := requests[1]
+	if !retry.JSON || len(retry.Messages) != 3 {
+		t.Fatalf("retry = %+v, want a JSON request continuing the conversation", retry)
+	}
+	if retry.Messages[1].Role != tools.RoleAssistant || retry.Messages[1].Content != invalid {
+		t.Errorf("rejected response not sent back: %+v", retry.Messages[1])
+	}
+	if !strings.Contains(retry.Messages[2].Content, "topic is required") {
+		t.Errorf("correction %q does not name the validation error", retry.Messages[2].Content)
+	}
+}
+
+func TestGenerateJSONGivesUp(t *testing.T) {
+	a, llm := structuredAgent(t, tools.Script{Default: "I would pick the Go release."})
+
+	_, err := a.Plan(context.Background(), planTrends, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("err = %v, want the last decoding error after 3 attempts", err)
+	}
+	if got := len(llm.Requests()); got != maxStructuredAttempts {
+		t.Errorf("%d requests, want %d", got, maxStructuredAttempts)
+	}
+}
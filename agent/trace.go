@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"content-creator-agent/tools/logger"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Run kinds recorded on traces.
+const (
+	RunKindPublish = "run"
+	RunKindPlan    = "plan"
+	RunKindReview  = "review"
+	RunKindPreview = "preview"
+)
+
+// beginRun resets the per-run state and starts a new trace.
+func (a *Agent) beginRun(kind string) {
+	a.runAt = time.Now()
+	a.rejections = nil
+	a.trace = &models.RunTrace{
+		ID:        fmt.Sprintf("run-%d", a.runAt.UnixNano()),
+		BrandID:   a.Brand.ID,
+		Kind:      kind,
+		Status:    models.RunStatusRunning,
+		StartedAt: a.runAt,
+	}
+	logger.GlobalBuffer.Info("Run %s started", a.trace.ID)
+}
+
+// endRun persists the guardrail rejections and the trace of the current run.
+func (a *Agent) endRun(err error) {
+	a.flushRejections()
+	if a.trace == nil {
+		return
+	}
+
+	a.trace.FinishedAt = time.Now()
+	a.trace.Status = models.RunStatusSucceeded
+	if err != nil {
+		a.trace.Status = models.RunStatusFailed
+		a.trace.Error = err.Error()
+	}
+	a.trace.LLMCallCount = len(a.trace.LLMCalls)
+	if saveErr := a.Store.SaveRunTrace(*a.trace); saveErr != nil {
+		logger.GlobalBuffer.Error("Warning: Failed to save run trace %s: %v", a.trace.ID, saveErr)
+	}
+	a.trace = nil
+}
+
+// traceStep records a finished pipeline step.
+func (a *Agent) traceStep(name string, round int, started time.Time, err error) {
+	if a.trace == nil {
+		return
+	}
+	step := models.TraceStep{
+		Name:       name,
+		Round:      round,
+		StartedAt:  started,
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	a.trace.Steps = append(a.trace.Steps, step)
+}
+
+// llmFor picks the LLM for a pipeline step: the brand's provider for that step,
+// then the brand-wide provider, then the agent's default LLM. Unknown provider
+// names fall back to the default with a warning.
+func (a *Agent) llmFor(step string) (string, tools.LLMTool) {
+	for _, name := range []string{a.Brand.StepProviders[step], a.Brand.LLMProvider} {
+		if name == "" {
+			continue
+		}
+		if llm, ok := a.Providers[name]; ok {
+			return name, llm
+		}
+		logger.GlobalBuffer.Warn("LLM provider %q is not configured, using the default", name)
+	}
+	return "", a.LLM
+}
+
+// generate renders the named prompt and sends it to the LLM of the current step.
+func (a *Agent) generate(ctx context.Context, prompt string, data interface{}) (string, error) {
+	req, tmpl, err := a.render(prompt, data)
+	if err != nil {
+		return "", err
+	}
+	res, err := a.complete(ctx, tmpl, req)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+// StreamEvent is a chunk of LLM output delivered to Agent.OnDelta.
+type StreamEvent struct {
+	Step    string `json:"step"`
+	Variant string `json:"variant,omitempty"` // Variant being drafted, e.g. "twitter/es-MX"
+	Delta   string `json:"delta"`
+}
+
+// complete calls the LLM of the current step and records the exchange, and
+// the template it was rendered from, in the run trace. With OnDelta set the
+// response is streamed as it arrives.
+func (a *Agent) complete(ctx context.Context, tmpl PromptTemplate, req tools.LLMRequest) (*tools.LLMResponse, error) {
+	provider, llm := a.llmFor(a.step)
+	var onDelta func(string)
+	if a.OnDelta != nil {
+		step, variant := a.step, a.variant
+		onDelta = func(delta string) {
+			a.OnDelta(StreamEvent{Step: step, Variant: variant, Delta: delta})
+		}
+	}
+	started := time.Now()
+	res, err := tools.Stream(ctx, llm, req, onDelta)
+
+	if a.trace != nil {
+		call := models.LLMCall{
+			Step:         a.step,
+			Provider:     provider,
+			Prompt:       tmpl.Label(),
+			SystemPrompt: req.System,
+			UserPrompt:   transcript(req.Messages),
+			LatencyMS:    time.Since(started).Milliseconds(),
+			StartedAt:    started,
+		}
+		if err != nil {
+			call.Error = err.Error()
+		} else {
+			call.Model = res.Model
+			call.FinishReason = res.FinishReason
+			call.Cached = res.Cached
+			call.Response = res.Text
+			call.PromptTokens = res.Usage.PromptTokens
+			call.CompletionTokens = res.Usage.CompletionTokens
+			call.TotalTokens = res.Usage.TotalTokens
+		}
+		a.trace.LLMCalls = append(a.trace.LLMCalls, call)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	a.recordUsage(models.UsageKindLLM, provider, res.Model, res.Usage, res.Cached)
+	return res, nil
+}
+
+// transcript flattens a conversation for the trace. A single user turn is
+// kept as is; longer conversations are labelled by role.
+func transcript(messages []tools.LLMMessage) string {
+	if len(messages) == 1 {
+		return messages[0].Content
+	}
+	var parts []string
+	for _, m := range messages {
+		parts = append(parts, fmt.Sprintf("[%s]\n%s", m.Role, m.Content))
+	}
+	return strings.Join(parts, "\n\n")
+}
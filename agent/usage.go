@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"content-creator-agent/tools/logger"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// recordUsage prices a call and appends it to the brand's usage ledger.
+// Cached calls are recorded at no cost.
+func (a *Agent) recordUsage(kind, provider, model string, usage tools.TokenUsage, cached bool) {
+	record := models.UsageRecord{
+		ID:               fmt.Sprintf("usage-%d", time.Now().UnixNano()),
+		BrandID:          a.Brand.ID,
+		UserID:           a.Brand.UserID,
+		Step:             a.step,
+		Kind:             kind,
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Cached:           cached,
+		CreatedAt:        time.Now(),
+	}
+	if a.trace != nil {
+		record.RunID = a.trace.ID
+	}
+	if !cached {
+		record.CostUSD = a.Prices.Cost(model, usage.PromptTokens, usage.CompletionTokens)
+		if _, ok := a.Prices.Lookup(model); !ok {
+			warnUnpriced(model)
+		}
+	}
+	if err := a.Store.SaveUsage(record); err != nil {
+		logger.GlobalBuffer.Warn("Failed to record %s usage: %v", kind, err)
+	}
+}
+
+// unpricedModels remembers the models warned about by warnUnpriced.
+var unpricedModels sync.Map
+
+// warnUnpriced logs, once per process, that calls to a model are free to the budget checks.
+func warnUnpriced(model string) {
+	if _, warned := unpricedModels.LoadOrStore(model, true); !warned {
+		logger.GlobalBuffer.Warn("Model %q has no price; its calls are recorded at $0 and budgets do not limit them. Add it to the price table to enforce budgets", model)
+	}
+}
+
+// embed embeds texts for task in one batch and records the usage. It also
+// returns the model that produced the vectors, which differs from the
+// configured one when the embedder fell back.
+func (a *Agent) embed(task string, texts ...string) ([][]float32, string, error) {
+	return a.embedWith(a.Embedding, task, texts...)
+}
+
+func (a *Agent) embedWith(e tools.EmbeddingTool, task string, texts ...string) ([][]float32, string, error) {
+	vecs, usage, err := tools.EmbedMetered(e, task, texts...)
+	if err != nil {
+		return nil, "", err
+	}
+	a.recordUsage(models.UsageKindEmbedding, "", usage.Model, tools.TokenUsage{PromptTokens: usage.Tokens, TotalTokens: usage.Tokens}, usage.Cached)
+	return vecs, usage.Model, nil
+}
+
+// monthStart is the start of the UTC calendar month containing t, the window budgets are checked over.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SummarizeSpend totals the records of the UTC month containing now. Budgets
+// are left for the caller to fill in.
+func SummarizeSpend(records []models.UsageRecord, now time.Time) models.SpendSummary {
+	summary := models.SpendSummary{
+		ByModel: make(map[string]float64),
+		ByStep:  make(map[string]float64),
+		ByBrand: make(map[string]float64),
+		Days:    []models.DailySpend{},
+	}
+	start := monthStart(now)
+	today := now.UTC().Format("2006-01-02")
+	days := make(map[string]*models.DailySpend)
+
+	for _, r := range records {
+		if r.CreatedAt.Before(start) {
+			continue
+		}
+		date := r.CreatedAt.UTC().Format("2006-01-02")
+		summary.MonthUSD += r.CostUSD
+		if date == today {
+			summary.TodayUSD += r.CostUSD
+		}
+		summary.Calls++
+		summary.Tokens += r.TotalTokens
+
+		model := r.Model
+		if model == "" {
+			model = "unknown"
+		}
+		step := r.Step
+		if step == "" {
+			step = "other"
+		}
+		summary.ByModel[model] += r.CostUSD
+		summary.ByStep[step] += r.CostUSD
+		summary.ByBrand[r.BrandID] += r.CostUSD
+
+		day, ok := days[date]
+		if !ok {
+			day = &models.DailySpend{Date: date}
+			days[date] = day
+		}
+		day.CostUSD += r.CostUSD
+		day.Tokens += r.TotalTokens
+	}
+
+	for _, d := range days {
+		summary.Days = append(summary.Days, *d)
+	}
+	sort.Slice(summary.Days, func(i, j int) bool { return summary.Days[i].Date < summary.Days[j].Date })
+	return summary
+}
+
+// NoteUnpriced lists the models in the summary that prices does not list,
+// whose spend the summary and the budget checks count as zero.
+func NoteUnpriced(summary *models.SpendSummary, prices tools.PriceTable) {
+	summary.UnpricedModels = nil
+	for model := range summary.ByModel {
+		if _, ok := prices.Lookup(model); !ok {
+			summary.UnpricedModels = append(summary.UnpricedModels, model)
+		}
+	}
+	sort.Strings(summary.UnpricedModels)
+}
+
+// overBudget reports whether spend has reached a non-zero budget.
+func overBudget(spent, budget float64) bool {
+	return budget > 0 && spent >= budget
+}
+
+// BrandSpend reports the brand's spend this month against its budgets.
+func BrandSpend(store memory.Store, brand models.BrandProfile, now time.Time) (models.SpendSummary, error) {
+	records, err := store.GetUsage(brand.ID, "", monthStart(now))
+	if err != nil {
+		return models.SpendSummary{}, err
+	}
+	summary := SummarizeSpend(records, now)
+	summary.BrandID = brand.ID
+	summary.ByBrand = nil
+	summary.DailyBudgetUSD = brand.DailyBudgetUSD
+	summary.MonthlyBudgetUSD = brand.MonthlyBudgetUSD
+	summary.OverBudget = overBudget(summary.TodayUSD, brand.DailyBudgetUSD) || overBudget(summary.MonthUSD, brand.MonthlyBudgetUSD)
+	return summary, nil
+}
+
+// UserSpend reports a user's spend this month across all their brands against their budgets.
+func UserSpend(store memory.Store, user models.User, now time.Time) (models.SpendSummary, error) {
+	records, err := store.GetUsage("", user.ID, monthStart(now))
+	if err != nil {
+		return models.SpendSummary{}, err
+	}
+	summary := SummarizeSpend(records, now)
+	summary.UserID = user.ID
+	summary.DailyBudgetUSD = user.DailyBudgetUSD
+	summary.MonthlyBudgetUSD = user.MonthlyBudgetUSD
+	summary.OverBudget = overBudget(summary.TodayUSD, user.DailyBudgetUSD) || overBudget(summary.MonthUSD, user.MonthlyBudgetUSD)
+	return summary, nil
+}
+
+// CheckBudget returns an error describing the exhausted budget when the brand,
+// or the user who owns it, has used up a daily or monthly budget.
+func CheckBudget(store memory.Store, brand models.BrandProfile) error {
+	now := time.Now()
+	spend, err := BrandSpend(store, brand, now)
+	if err != nil {
+		return fmt.Errorf("failed to read spend: %w", err)
+	}
+	if spend.OverBudget {
+		return fmt.Errorf("brand %s has spent $%.2f today and $%.2f this month (budgets $%.2f/day, $%.2f/month)",
+			brand.ID, spend.TodayUSD, spend.MonthUSD, spend.DailyBudgetUSD, spend.MonthlyBudgetUSD)
+	}
+
+	if brand.UserID == "" {
+		return nil
+	}
+	user, err := store.GetUserByID(brand.UserID)
+	if err != nil || (user.DailyBudgetUSD <= 0 && user.MonthlyBudgetUSD <= 0) {
+		return nil
+	}
+	userSpend, err := UserSpend(store, *user, now)
+	if err != nil {
+		return fmt.Errorf("failed to read spend: %w", err)
+	}
+	if userSpend.OverBudget {
+		return fmt.Errorf("user %s has spent $%.2f today and $%.2f this month (budgets $%.2f/day, $%.2f/month)",
+			user.ID, userSpend.TodayUSD, userSpend.MonthUSD, userSpend.DailyBudgetUSD, userSpend.MonthlyBudgetUSD)
+	}
+	return nil
+}
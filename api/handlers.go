@@ -1,16 +1,19 @@
 package api
 
 import (
+	"content-creator-agent/agent"
 	"content-creator-agent/memory"
 	"content-creator-agent/models"
 	"content-creator-agent/scheduler"
 	"content-creator-agent/tools"
 	"content-creator-agent/tools/logger"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -24,9 +27,12 @@ type Handlers struct {
 	// Tools needed to construct agents on-the-fly per brand
 	Search    tools.SearchTool
 	LLM       tools.LLMTool
+	Providers map[string]tools.LLMTool
 	Social    tools.SocialClient
 	Embedding tools.EmbeddingTool
 	Analytics tools.AnalyticsFetcher
+	Cache     *tools.Cache // nil when caching is disabled
+	Prices    tools.PriceTable
 	DataDir   string
 }
 
@@ -116,6 +122,14 @@ func (h *Handlers) CreateBrand(w http.ResponseWriter, r *http.Request) {
 		Error(w, http.StatusBadRequest, "brand id and name are required")
 		return
 	}
+	if err := validatePrompts(brand.Prompts); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := agent.ValidatePipeline(brand.Pipeline); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Prefix brand ID with user ID for uniqueness in multi-tenant DB if needed,
 	// but with P0 DB we just store user_id in the row.
@@ -159,6 +173,14 @@ func (h *Handlers) UpdateBrand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	brand.ID = brandID
+	if err := validatePrompts(brand.Prompts); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := agent.ValidatePipeline(brand.Pipeline); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	if err := h.Store.SaveBrand(brand, userID); err != nil {
 		Error(w, http.StatusInternalServerError, "failed to update brand")
@@ -177,6 +199,101 @@ func (h *Handlers) DeleteBrand(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, map[string]string{"deleted": brandID})
 }
 
+// validatePrompts checks every prompt override of a brand.
+func validatePrompts(prompts map[string]string) error {
+	for name, text := range prompts {
+		if err := agent.ValidatePrompt(name, text); err != nil {
+			return fmt.Errorf("invalid %s prompt: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// --- Prompt Handlers ---
+
+// ListPrompts returns every built-in prompt template version.
+func (h *Handlers) ListPrompts(w http.ResponseWriter, r *http.Request) {
+	JSON(w, http.StatusOK, agent.BuiltinPrompts())
+}
+
+// GetBrandPrompts returns the template the brand uses for every prompt.
+func (h *Handlers) GetBrandPrompts(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	brand, _, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	prompts, err := agent.BrandPrompts(brand)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	JSON(w, http.StatusOK, prompts)
+}
+
+// UpdateBrandPrompt overrides one prompt for the brand. The body's template is
+// either a full template or a built-in version such as "v1" to pin.
+func (h *Handlers) UpdateBrandPrompt(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	name := chi.URLParam(r, "name")
+	var req struct {
+		Template string `json:"template"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := agent.ValidatePrompt(name, req.Template); err != nil {
+		Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	brand, owner, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	if brand.Prompts == nil {
+		brand.Prompts = make(map[string]string)
+	}
+	brand.Prompts[name] = req.Template
+	if err := h.Store.SaveBrand(brand, owner); err != nil {
+		Error(w, http.StatusInternalServerError, "failed to update brand")
+		return
+	}
+
+	prompt, _ := agent.PromptFor(brand, name)
+	JSON(w, http.StatusOK, prompt)
+}
+
+// DeleteBrandPrompt removes the brand's override of a prompt and returns the
+// built-in template now in use.
+func (h *Handlers) DeleteBrandPrompt(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	name := chi.URLParam(r, "name")
+	brand, owner, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	if _, err := agent.PromptFor(models.BrandProfile{}, name); err != nil {
+		Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if _, ok := brand.Prompts[name]; ok {
+		delete(brand.Prompts, name)
+		if err := h.Store.SaveBrand(brand, owner); err != nil {
+			Error(w, http.StatusInternalServerError, "failed to update brand")
+			return
+		}
+	}
+
+	prompt, _ := agent.PromptFor(brand, name)
+	JSON(w, http.StatusOK, prompt)
+}
+
 // --- Agent Action Handlers ---
 
 func (h *Handlers) TriggerRun(w http.ResponseWriter, r *http.Request) {
@@ -198,6 +315,41 @@ func (h *Handlers) TriggerRun(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// PreviewRun performs a synchronous dry run of the agent cycle and returns what it
+// would have published. Nothing is posted or saved. A preview that runs past
+// previewTimeout returns the variants finished so far. Previews reuse cached
+// LLM responses unless ?fresh=true is set.
+func (h *Handlers) PreviewRun(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	brand, _, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	if err := agent.CheckBudget(h.Store, brand); err != nil {
+		Error(w, http.StatusPaymentRequired, err.Error())
+		return
+	}
+
+	factory := scheduler.DefaultAgentFactory(h.Store, h.Search, h.LLM, h.Providers, h.Social, h.Embedding, h.Analytics, h.Prices, h.DataDir)
+	a, err := factory(brandID)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, "failed to create agent")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
+	if r.URL.Query().Get("fresh") != "true" {
+		ctx = tools.WithCache(ctx)
+	}
+	report, err := a.Preview(ctx)
+	if err != nil {
+		logger.GlobalBuffer.Warn("Preview for brand %s stopped early: %v", brandID, err)
+	}
+	JSON(w, http.StatusOK, report)
+}
+
 func (h *Handlers) TriggerSync(w http.ResponseWriter, r *http.Request) {
 	brandID := chi.URLParam(r, "brandID")
 	if _, _, err := h.Store.GetBrand(brandID); err != nil {
@@ -288,11 +440,111 @@ func (h *Handlers) UpdateScheduledPost(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
+func (h *Handlers) GetGuardRejections(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	rejections, err := h.Store.GetGuardRejections(brandID)
+	if err != nil {
+		JSON(w, http.StatusOK, []models.GuardRejection{})
+		return
+	}
+	JSON(w, http.StatusOK, rejections)
+}
+
+func (h *Handlers) ListRuns(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	traces, err := h.Store.GetRunTraces(brandID, 50)
+	if err != nil {
+		JSON(w, http.StatusOK, []models.RunTrace{})
+		return
+	}
+	JSON(w, http.StatusOK, traces)
+}
+
+func (h *Handlers) GetRun(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	runID := chi.URLParam(r, "runID")
+	trace, err := h.Store.GetRunTrace(brandID, runID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "run not found")
+		return
+	}
+	JSON(w, http.StatusOK, trace)
+}
+
 func (h *Handlers) GetLogs(w http.ResponseWriter, r *http.Request) {
 	entries := logger.GlobalBuffer.GetEntries()
 	JSON(w, http.StatusOK, entries)
 }
 
+// --- Spend Handlers ---
+
+func (h *Handlers) GetBrandSpend(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	brand, _, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+
+	spend, err := agent.BrandSpend(h.Store, brand, time.Now())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, "failed to load spend")
+		return
+	}
+	agent.NoteUnpriced(&spend, h.Prices)
+	JSON(w, http.StatusOK, spend)
+}
+
+func (h *Handlers) GetUserSpend(w http.ResponseWriter, r *http.Request) {
+	user, err := h.Store.GetUserByID(GetUserID(r))
+	if err != nil {
+		Error(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	spend, err := agent.UserSpend(h.Store, *user, time.Now())
+	if err != nil {
+		Error(w, http.StatusInternalServerError, "failed to load spend")
+		return
+	}
+	agent.NoteUnpriced(&spend, h.Prices)
+	JSON(w, http.StatusOK, spend)
+}
+
+func (h *Handlers) UpdateUserBudget(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DailyBudgetUSD   float64 `json:"daily_budget_usd"`
+		MonthlyBudgetUSD float64 `json:"monthly_budget_usd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.DailyBudgetUSD < 0 || req.MonthlyBudgetUSD < 0 {
+		Error(w, http.StatusBadRequest, "budgets cannot be negative")
+		return
+	}
+
+	if err := h.Store.UpdateUserBudget(GetUserID(r), req.DailyBudgetUSD, req.MonthlyBudgetUSD); err != nil {
+		Error(w, http.StatusInternalServerError, "failed to update budget")
+		return
+	}
+	JSON(w, http.StatusOK, req)
+}
+
+func (h *Handlers) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	if h.Cache == nil {
+		JSON(w, http.StatusOK, tools.CacheStats{})
+		return
+	}
+	stats, err := h.Cache.Stats()
+	if err != nil {
+		Error(w, http.StatusInternalServerError, "failed to read cache stats")
+		return
+	}
+	JSON(w, http.StatusOK, stats)
+}
+
 func (h *Handlers) ListPosts(w http.ResponseWriter, r *http.Request) {
 	brandID := chi.URLParam(r, "brandID")
 	posts, err := h.Store.GetHistory(brandID)
@@ -313,6 +565,35 @@ func (h *Handlers) GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	JSON(w, http.StatusOK, analytics)
 }
 
+// GetPillarMix reports how the brand's posted topics split across its content
+// pillars compared with the configured targets.
+func (h *Handlers) GetPillarMix(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	brand, _, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	history, err := h.Store.GetHistory(brandID)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, "failed to load posts")
+		return
+	}
+	JSON(w, http.StatusOK, agent.PillarMix(brand, history))
+}
+
+// GetPromptPerformance compares the engagement and critic scores of the
+// brand's posts by prompt version. ?prompt=generate limits it to one prompt.
+func (h *Handlers) GetPromptPerformance(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	history, err := h.Store.GetHistory(brandID)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, "failed to load posts")
+		return
+	}
+	JSON(w, http.StatusOK, agent.PromptPerformance(history, r.URL.Query().Get("prompt")))
+}
+
 func (h *Handlers) ListGlobalPosts(w http.ResponseWriter, r *http.Request) {
 	userID := GetUserID(r)
 	posts, err := h.Store.GetGlobalHistory(userID, 0) // 0 means no limit
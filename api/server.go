@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"expvar"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -12,6 +13,13 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// requestTimeout bounds every request except the streaming ones and previews.
+const requestTimeout = 60 * time.Second
+
+// previewTimeout bounds a synchronous preview, which runs the whole review loop
+// for every platform before it answers.
+const previewTimeout = 5 * time.Minute
+
 // Server holds the HTTP server and all its dependencies.
 type Server struct {
 	Router    *chi.Mux
@@ -38,13 +46,31 @@ func (s *Server) mountMiddleware() {
 	s.Router.Use(middleware.RealIP)
 	s.Router.Use(middleware.Logger)
 	s.Router.Use(middleware.Recoverer)
-	s.Router.Use(middleware.Timeout(60 * time.Second))
 	s.Router.Use(corsMiddleware)
 }
 
 func (s *Server) mountRoutes() {
 	r := s.Router
 
+	// Streaming routes hold the connection for the whole run and are cancelled
+	// when the client goes away, so they sit outside the request timeout.
+	// Synchronous previews set their own, longer deadline.
+	r.Group(func(r chi.Router) {
+		r.Use(s.AuthMiddleware)
+
+		r.Post("/api/brands/{brandID}/preview", s.Handlers.PreviewRun)
+		r.Post("/api/brands/{brandID}/preview/stream", s.Handlers.PreviewRunStream)
+		r.Post("/api/brands/{brandID}/generate/stream", s.Handlers.GenerateStream)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(requestTimeout))
+		s.mountTimedRoutes(r)
+	})
+}
+
+// mountTimedRoutes mounts every route served under the request timeout.
+func (s *Server) mountTimedRoutes(r chi.Router) {
 	// Public routes
 	r.Post("/api/auth/register", s.Handlers.Register)
 	r.Post("/api/auth/login", s.Handlers.Login)
@@ -57,6 +83,10 @@ func (s *Server) mountRoutes() {
 		r.Get("/api/auth/me", s.Handlers.GetMe)
 		r.Get("/api/analytics", s.Handlers.GetGlobalAnalytics)
 		r.Get("/api/posts", s.Handlers.ListGlobalPosts)
+		r.Get("/api/cache/stats", s.Handlers.GetCacheStats)
+		r.Get("/api/metrics", expvar.Handler().ServeHTTP) // Provider counters, cmdline and memstats
+		r.Get("/api/spend", s.Handlers.GetUserSpend)
+		r.Put("/api/spend/budget", s.Handlers.UpdateUserBudget)
 
 		// Brands
 		r.Post("/api/brands", s.Handlers.CreateBrand)
@@ -65,6 +95,12 @@ func (s *Server) mountRoutes() {
 		r.Put("/api/brands/{brandID}", s.Handlers.UpdateBrand)
 		r.Delete("/api/brands/{brandID}", s.Handlers.DeleteBrand)
 
+		// Prompt Templates
+		r.Get("/api/prompts", s.Handlers.ListPrompts)
+		r.Get("/api/brands/{brandID}/prompts", s.Handlers.GetBrandPrompts)
+		r.Put("/api/brands/{brandID}/prompts/{name}", s.Handlers.UpdateBrandPrompt)
+		r.Delete("/api/brands/{brandID}/prompts/{name}", s.Handlers.DeleteBrandPrompt)
+
 		// Agent Actions
 		r.Post("/api/brands/{brandID}/run", s.Handlers.TriggerRun)
 		r.Post("/api/brands/{brandID}/sync", s.Handlers.TriggerSync)
@@ -78,6 +114,14 @@ func (s *Server) mountRoutes() {
 		// Posts & Analytics
 		r.Get("/api/brands/{brandID}/posts", s.Handlers.ListPosts)
 		r.Get("/api/brands/{brandID}/analytics", s.Handlers.GetAnalytics)
+		r.Get("/api/brands/{brandID}/analytics/pillars", s.Handlers.GetPillarMix)
+		r.Get("/api/brands/{brandID}/analytics/prompts", s.Handlers.GetPromptPerformance)
+		r.Get("/api/brands/{brandID}/guardrails", s.Handlers.GetGuardRejections)
+
+		// Run Traces
+		r.Get("/api/brands/{brandID}/runs", s.Handlers.ListRuns)
+		r.Get("/api/brands/{brandID}/runs/{runID}", s.Handlers.GetRun)
+		r.Get("/api/brands/{brandID}/spend", s.Handlers.GetBrandSpend)
 	})
 
 	// Static files for Dashboard
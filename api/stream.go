@@ -0,0 +1,154 @@
+package api
+
+import (
+	"content-creator-agent/agent"
+	"content-creator-agent/models"
+	"content-creator-agent/scheduler"
+	"content-creator-agent/tools"
+	"content-creator-agent/tools/logger"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sseWriter writes server-sent events to a response.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newSSEWriter sends the event stream headers. It fails when the response
+// cannot be flushed incrementally.
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported by this connection")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+// Send writes one event with data encoded as JSON.
+func (s *sseWriter) Send(event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.GlobalBuffer.Error("Failed to encode %s event: %v", event, err)
+		return
+	}
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+	s.flusher.Flush()
+}
+
+// streamingAgent builds the brand's agent with its progress wired to events:
+// "step" when a pipeline step starts and "delta" for every chunk of LLM output.
+func (h *Handlers) streamingAgent(brandID string, events *sseWriter) (*agent.Agent, error) {
+	factory := scheduler.DefaultAgentFactory(h.Store, h.Search, h.LLM, h.Providers, h.Social, h.Embedding, h.Analytics, h.Prices, h.DataDir)
+	a, err := factory(brandID)
+	if err != nil {
+		return nil, err
+	}
+	a.BeforeStep = append(a.BeforeStep, func(step string, rc *agent.RunContext) error {
+		events.Send("step", map[string]interface{}{"step": step, "round": rc.Round})
+		return nil
+	})
+	a.OnDelta = func(e agent.StreamEvent) {
+		events.Send("delta", e)
+	}
+	return a, nil
+}
+
+// PreviewRunStream performs the same dry run as PreviewRun but streams the
+// drafts to the dashboard as they are written. The final event is "report"
+// with the RunReport, or "error". Closing the connection cancels the run.
+// Like PreviewRun it reuses cached LLM responses unless ?fresh=true is set.
+func (h *Handlers) PreviewRunStream(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	brand, _, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	if err := agent.CheckBudget(h.Store, brand); err != nil {
+		Error(w, http.StatusPaymentRequired, err.Error())
+		return
+	}
+
+	events, err := newSSEWriter(w)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a, err := h.streamingAgent(brandID, events)
+	if err != nil {
+		events.Send("error", map[string]string{"error": "failed to create agent"})
+		return
+	}
+
+	ctx := r.Context()
+	if r.URL.Query().Get("fresh") != "true" {
+		ctx = tools.WithCache(ctx)
+	}
+	report, err := a.Preview(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.GlobalBuffer.Info("Preview for brand %s cancelled by the client", brandID)
+			return
+		}
+		logger.GlobalBuffer.Warn("Preview for brand %s stopped early: %v", brandID, err)
+	}
+	events.Send("report", report)
+}
+
+// GenerateStream runs a review cycle in the request and streams the drafts as
+// they are written. Approved variants are saved as pending_review and listed
+// in the final "done" event; a failed run ends with "error". Closing the
+// connection cancels the run.
+func (h *Handlers) GenerateStream(w http.ResponseWriter, r *http.Request) {
+	brandID := chi.URLParam(r, "brandID")
+	brand, _, err := h.Store.GetBrand(brandID)
+	if err != nil {
+		Error(w, http.StatusNotFound, "brand not found")
+		return
+	}
+	if err := agent.CheckBudget(h.Store, brand); err != nil {
+		Error(w, http.StatusPaymentRequired, err.Error())
+		return
+	}
+
+	events, err := newSSEWriter(w)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a, err := h.streamingAgent(brandID, events)
+	if err != nil {
+		events.Send("error", map[string]string{"error": "failed to create agent"})
+		return
+	}
+
+	scheduled := []models.ScheduledPost{}
+	a.AfterStep = append(a.AfterStep, func(step string, rc *agent.RunContext) error {
+		if step == agent.StepPublish {
+			scheduled = rc.Scheduled
+		}
+		return nil
+	})
+
+	ctx := r.Context()
+	if err := a.RunForReview(ctx); err != nil {
+		if ctx.Err() != nil {
+			logger.GlobalBuffer.Info("Generation for brand %s cancelled by the client", brandID)
+			return
+		}
+		events.Send("error", map[string]string{"error": err.Error()})
+		return
+	}
+	events.Send("done", map[string]interface{}{"scheduled": scheduled})
+}
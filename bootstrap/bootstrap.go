@@ -0,0 +1,274 @@
+// Package bootstrap builds the providers shared by the CLI and the API server
+// from environment variables.
+package bootstrap
+
+import (
+	"content-creator-agent/tools"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options are the settings that come from flags rather than the environment.
+type Options struct {
+	DataDir string // Directory of the response cache
+	UseDDG  bool   // Search with DuckDuckGo even when NEWSAPI_KEY is set
+}
+
+// Tools are the providers every brand's agent is built from.
+type Tools struct {
+	Search    tools.SearchTool
+	LLM       tools.LLMTool            // Default provider, wrapped in the LLM_FALLBACK chain
+	Providers map[string]tools.LLMTool // Providers brands can select by name
+	Embedding tools.EmbeddingTool      // Nil when brands only embed with their local embedder
+	Social    *tools.MultiSocialClient
+	Analytics *tools.MultiAnalyticsFetcher
+	Prices    tools.PriceTable
+	Cache     *tools.Cache // Nil when caching is off
+	Fake      bool         // FAKE_PROVIDERS is set
+}
+
+// FromEnv builds the providers configured in the environment.
+//
+// FAKE_PROVIDERS=true replaces search, the LLM, embeddings, social accounts
+// and analytics with offline fakes; the LLM and search are driven by
+// FAKE_LLM_SCRIPT and FAKE_SEARCH_FIXTURES. No API keys are needed and
+// configured credentials are ignored.
+func FromEnv(opts Options) (*Tools, error) {
+	t := &Tools{Fake: os.Getenv("FAKE_PROVIDERS") == "true"}
+	if opts.DataDir == "" {
+		opts.DataDir = "data"
+	}
+
+	geminiKey := os.Getenv("GEMINI_API_KEY")
+	// Fully local setups generate with Ollama and need no Gemini key.
+	local := os.Getenv("LLM_PROVIDER") == "ollama" && os.Getenv("OLLAMA_MODEL") != ""
+	if geminiKey == "" && !t.Fake && !local {
+		return nil, fmt.Errorf("GEMINI_API_KEY is required")
+	}
+
+	var err error
+	if t.Search, err = searchFromEnv(opts, t.Fake); err != nil {
+		return nil, err
+	}
+	if !t.Fake {
+		if t.Cache, err = cacheFromEnv(opts.DataDir); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.llmFromEnv(geminiKey); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	// PRICES_PATH points to a JSON price table that overrides the built-in prices.
+	pricesPath := os.Getenv("PRICES_PATH")
+	if pricesPath == "" {
+		pricesPath = "config/prices.json"
+	}
+	if t.Prices, err = tools.LoadPriceTable(pricesPath); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("failed to load price table: %w", err)
+	}
+
+	t.embeddingFromEnv(geminiKey)
+	t.socialFromEnv()
+	if t.Fake {
+		fmt.Println("🧪 Using fake providers, no external APIs will be called.")
+	}
+	return t, nil
+}
+
+// Close releases the response cache.
+func (t *Tools) Close() error {
+	if t.Cache == nil {
+		return nil
+	}
+	return t.Cache.Close()
+}
+
+func searchFromEnv(opts Options, fake bool) (tools.SearchTool, error) {
+	if fake {
+		path := os.Getenv("FAKE_SEARCH_FIXTURES")
+		if path == "" {
+			path = "config/fake/trends.json"
+		}
+		fixtures, err := tools.LoadFixtureSearch(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load search fixtures: %w", err)
+		}
+		return fixtures, nil
+	}
+
+	ddg := tools.NewDuckDuckGoSearch()
+	newsKey := os.Getenv("NEWSAPI_KEY")
+	if opts.UseDDG || newsKey == "" {
+		if newsKey == "" && !opts.UseDDG {
+			fmt.Println("WARNING: NEWSAPI_KEY not set. Using DuckDuckGo.")
+		}
+		return ddg, nil
+	}
+	var primary tools.SearchTool
+	if strings.HasPrefix(newsKey, "pub_") {
+		primary = tools.NewNewsDataSearch(newsKey)
+	} else {
+		primary = tools.NewNewsAPISearch(newsKey)
+	}
+	return tools.NewResilientSearch(primary, ddg), nil
+}
+
+// cacheFromEnv opens the response cache. LLM_CACHE=off disables it;
+// LLM_CACHE_TTL overrides how long LLM responses are kept. Scripted responses
+// are never cached so that repeated prompts keep cycling through them.
+func cacheFromEnv(dataDir string) (*tools.Cache, error) {
+	if os.Getenv("LLM_CACHE") == "off" {
+		return nil, nil
+	}
+	os.MkdirAll(dataDir, 0755)
+	cache, err := tools.OpenCache(filepath.Join(dataDir, "cache.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("LLM_CACHE_TTL")); err == nil {
+		cache.TTL = ttl
+	}
+	return cache, nil
+}
+
+// llmFromEnv sets up the LLM providers. Brands can select one by name;
+// LLM_PROVIDER picks the default and LLM_FALLBACK lists providers to try, in
+// order, when the default fails.
+func (t *Tools) llmFromEnv(geminiKey string) error {
+	providers := map[string]tools.LLMTool{}
+	defaultProvider := os.Getenv("LLM_PROVIDER")
+	if t.Fake {
+		path := os.Getenv("FAKE_LLM_SCRIPT")
+		if path == "" {
+			path = "config/fake/llm.json"
+		}
+		fake, err := tools.LoadScriptedLLM(path)
+		if err != nil {
+			return fmt.Errorf("failed to load LLM script: %w", err)
+		}
+		providers["fake"] = fake
+		defaultProvider = "fake"
+	} else {
+		providers["gemini"] = tools.NewGeminiClient(geminiKey, "gemini-3-flash-preview")
+		if os.Getenv("OPENAI_BASE_URL") != "" || os.Getenv("OPENAI_API_KEY") != "" {
+			oc := tools.NewOpenAIClient(os.Getenv("OPENAI_BASE_URL"), os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_MODEL"))
+			oc.APIKeyHeader = os.Getenv("OPENAI_API_KEY_HEADER")
+			providers["openai"] = oc
+		}
+		if anthropicKey := os.Getenv("ANTHROPIC_API_KEY"); anthropicKey != "" {
+			providers["anthropic"] = tools.NewAnthropicClient(anthropicKey, os.Getenv("ANTHROPIC_MODEL"))
+		}
+		if ollamaModel := os.Getenv("OLLAMA_MODEL"); ollamaModel != "" {
+			providers["ollama"] = tools.NewOllamaClient(ollamaModel)
+		}
+		if _, ok := providers[defaultProvider]; !ok {
+			defaultProvider = "gemini"
+		}
+	}
+	for name, p := range providers {
+		providers[name] = tools.NewCachedLLM(t.Cache, name, tools.ModelName(p), tools.NewResilientLLM(name, p))
+	}
+
+	chain := []tools.NamedLLM{{Name: defaultProvider, LLM: providers[defaultProvider]}}
+	for _, name := range strings.Split(os.Getenv("LLM_FALLBACK"), ",") {
+		name = strings.TrimSpace(name)
+		if p, ok := providers[name]; ok && name != defaultProvider {
+			chain = append(chain, tools.NamedLLM{Name: name, LLM: p})
+		}
+	}
+	t.Providers = providers
+	t.LLM = chain[0].LLM
+	if len(chain) > 1 {
+		t.LLM = tools.NewFallbackLLM(chain...)
+	}
+	return nil
+}
+
+// embeddingFromEnv sets up the shared embedder. EMBEDDING_PROVIDER=ollama
+// embeds with a local model (OLLAMA_EMBEDDING_MODEL); EMBEDDING_DIMENSIONS
+// shortens the vectors of models that support it. EMBEDDING_PROVIDER=local,
+// or no Gemini key, leaves every brand with its built-in local embedder,
+// which is also the fallback of the others.
+func (t *Tools) embeddingFromEnv(geminiKey string) {
+	if t.Fake {
+		t.Embedding = tools.NewHashEmbedding(0)
+		return
+	}
+	dims, _ := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSIONS"))
+	switch provider := os.Getenv("EMBEDDING_PROVIDER"); {
+	case provider == "ollama":
+		oe := tools.NewOllamaEmbeddingClient(os.Getenv("OLLAMA_EMBEDDING_MODEL"))
+		oe.Dimensions = dims
+		t.Embedding = tools.NewCachedEmbedding(t.Cache, "ollama", oe.Model, oe)
+	case provider == "local" || geminiKey == "":
+		// Left nil: each brand embeds with its tools.LocalEmbedding
+	default:
+		ge := tools.NewGeminiEmbeddingClient(geminiKey, "gemini-embedding-001")
+		ge.TaskType = tools.TaskRetrievalDocument
+		ge.Dimensions = dims
+		t.Embedding = tools.NewCachedEmbedding(t.Cache, "gemini", ge.Model, ge)
+	}
+}
+
+// socialFromEnv registers the social accounts and their analytics fetchers.
+// Without any account, and with fake providers, a mock client stands in for
+// every platform.
+func (t *Tools) socialFromEnv() {
+	t.Social = tools.NewMultiSocialClient()
+	t.Analytics = &tools.MultiAnalyticsFetcher{Fetchers: make(map[string]tools.AnalyticsFetcher)}
+	if t.Fake {
+		t.Social.AddClient("mock", &tools.MockSocialClient{Platform: "Mock"})
+		t.Analytics.Fetchers["mock"] = tools.MockAnalyticsFetcher{}
+		return
+	}
+
+	t.addTwitter("", "")
+	t.addLinkedIn("", "")
+	// Locale-specific accounts, e.g. TWITTER_API_KEY_ES_MX for SOCIAL_LOCALES=es-MX
+	for _, locale := range strings.Split(os.Getenv("SOCIAL_LOCALES"), ",") {
+		locale = strings.TrimSpace(locale)
+		if locale == "" {
+			continue
+		}
+		suffix := tools.LocaleEnvSuffix(locale)
+		t.addTwitter(locale, suffix)
+		t.addLinkedIn(locale, suffix)
+	}
+	accounts := len(t.Social.Clients)
+
+	// Meta Platforms (Stubs)
+	t.Social.AddClient("instagram", tools.NewInstagramClient())
+	t.Social.AddClient("tiktok", tools.NewTikTokClient())
+	t.Social.AddClient("threads", tools.NewThreadsClient())
+
+	if accounts == 0 {
+		t.Social.AddClient("mock", &tools.MockSocialClient{Platform: "Mock"})
+	}
+}
+
+func (t *Tools) addTwitter(locale, suffix string) {
+	key := os.Getenv("TWITTER_API_KEY" + suffix)
+	if key == "" {
+		return
+	}
+	tc := tools.NewTwitterClient(key, os.Getenv("TWITTER_API_SECRET"+suffix), os.Getenv("TWITTER_ACCESS_TOKEN"+suffix), os.Getenv("TWITTER_ACCESS_SECRET"+suffix))
+	t.Social.AddClient(tools.RouteKey("twitter", locale), tc)
+	t.Analytics.Fetchers[tools.RouteKey("twitter", locale)] = &tools.TwitterAnalyticsFetcher{Client: tc}
+}
+
+func (t *Tools) addLinkedIn(locale, suffix string) {
+	token := os.Getenv("LINKEDIN_ACCESS_TOKEN" + suffix)
+	if token == "" {
+		return
+	}
+	lc := tools.NewLinkedInClient(token, os.Getenv("LINKEDIN_PERSON_URN"+suffix))
+	t.Social.AddClient(tools.RouteKey("linkedin", locale), lc)
+	t.Analytics.Fetchers[tools.RouteKey("linkedin", locale)] = &tools.LinkedInAnalyticsFetcher{Client: lc}
+}
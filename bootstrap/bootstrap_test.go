@@ -0,0 +1,156 @@
+package bootstrap
+
+import (
+	"content-creator-agent/agent"
+	"content-creator-agent/memory"
+	"content-creator-agent/models"
+	"content-creator-agent/tools"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeEnv points FromEnv at the repository's fake provider fixtures and sets
+// real credentials that fake mode must ignore.
+func fakeEnv(t *testing.T) {
+	t.Setenv("FAKE_PROVIDERS", "true")
+	t.Setenv("FAKE_LLM_SCRIPT", "../config/fake/llm.json")
+	t.Setenv("FAKE_SEARCH_FIXTURES", "../config/fake/trends.json")
+	t.Setenv("PRICES_PATH", filepath.Join(t.TempDir(), "prices.json"))
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("TWITTER_API_KEY", "real-key")
+	t.Setenv("LINKEDIN_ACCESS_TOKEN", "real-token")
+	t.Setenv("SOCIAL_LOCALES", "es-MX")
+	t.Setenv("TWITTER_API_KEY_ES_MX", "real-key")
+}
+
+func TestFromEnvFakeReplacesEveryProvider(t *testing.T) {
+	fakeEnv(t)
+	shared, err := FromEnv(Options{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared.Close()
+
+	if _, ok := shared.Search.(*tools.FixtureSearch); !ok {
+		t.Errorf("search = %T, want fixtures", shared.Search)
+	}
+	if _, ok := shared.Embedding.(*tools.HashEmbedding); !ok {
+		t.Errorf("embedding = %T, want hashed embeddings", shared.Embedding)
+	}
+	if len(shared.Providers) != 1 || shared.Providers["fake"] == nil {
+		t.Errorf("providers = %v, want only the scripted LLM", shared.Providers)
+	}
+	if shared.Cache != nil {
+		t.Error("scripted responses are cached")
+	}
+	for key, client := range shared.Social.Clients {
+		if _, ok := client.(*tools.MockSocialClient); !ok || key != "mock" {
+			t.Errorf("social client %s is a %T", key, client)
+		}
+	}
+	for key, fetcher := range shared.Analytics.Fetchers {
+		if _, ok := fetcher.(tools.MockAnalyticsFetcher); !ok || key != "mock" {
+			t.Errorf("analytics fetcher %s is a %T", key, fetcher)
+		}
+	}
+}
+
+func TestFromEnvRequiresGeminiKey(t *testing.T) {
+	t.Setenv("FAKE_PROVIDERS", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("LLM_PROVIDER", "")
+	if _, err := FromEnv(Options{DataDir: t.TempDir()}); err == nil {
+		t.Error("started without an LLM")
+	}
+}
+
+// TestFakeRunEndToEnd drives a full publishing cycle and an analytics sync
+// for the sample brand with nothing but the fakes.
+func TestFakeRunEndToEnd(t *testing.T) {
+	fakeEnv(t)
+	dataDir := t.TempDir()
+	shared, err := FromEnv(Options{DataDir: dataDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared.Close()
+
+	config, err := os.ReadFile("../config/tech_startup.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var brand models.BrandProfile
+	if err := json.Unmarshal(config, &brand); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewFileStore(dataDir)
+	vector := memory.NewLocalVectorStore(filepath.Join(dataDir, brand.ID, "vectors.json"))
+	embedding := tools.NewFallbackEmbedding(shared.Embedding, tools.NewLocalEmbedding(filepath.Join(dataDir, brand.ID, "vocabulary.json")))
+	creator := agent.NewAgent(brand, shared.Search, shared.LLM, shared.Social, store, vector, embedding, shared.Analytics)
+	creator.Providers = shared.Providers
+	creator.Prices = shared.Prices
+
+	if err := creator.Run(context.Background()); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	history, err := store.GetHistory(brand.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := make(map[string]bool)
+	for _, post := range history {
+		if post.Status != models.StatusPublished {
+			t.Errorf("%s post %s is %s", post.Platform, post.ID, post.Status)
+			continue
+		}
+		if !strings.HasPrefix(post.SocialID, "mock-") {
+			t.Errorf("%s post published with social ID %q", post.Platform, post.SocialID)
+		}
+		if post.Content == "" || post.Topic == "" {
+			t.Errorf("%s post missing content or topic: %+v", post.Platform, post)
+		}
+		published[post.Platform] = true
+	}
+	for _, platform := range brand.Platforms {
+		if !published[platform] {
+			t.Errorf("nothing published to %s", platform)
+		}
+	}
+
+	traces, err := store.GetRunTraces(brand.ID, 1)
+	if err != nil || len(traces) != 1 || traces[0].Status != models.RunStatusSucceeded {
+		t.Fatalf("run trace = %+v, %v", traces, err)
+	}
+	usage, err := store.GetUsage(brand.ID, "", traces[0].StartedAt)
+	if err != nil || len(usage) == 0 {
+		t.Fatalf("no usage recorded: %v", err)
+	}
+	for _, r := range usage {
+		if r.CostUSD != 0 {
+			t.Errorf("fake %s call cost $%v", r.Model, r.CostUSD)
+		}
+		if _, priced := shared.Prices.Lookup(r.Model); !priced {
+			t.Errorf("fake model %s is not in the price table", r.Model)
+		}
+	}
+	if results, err := vector.Query(make([]float32, tools.DefaultHashDimensions), 10); err != nil || len(results) == 0 {
+		t.Errorf("no posts remembered: %v", err)
+	}
+
+	if err := creator.SyncAnalytics(); err != nil {
+		t.Fatal(err)
+	}
+	history, _ = store.GetHistory(brand.ID)
+	for _, post := range history {
+		want, _ := tools.MockAnalyticsFetcher{}.Fetch(&post)
+		if post.Analytics.Likes != want.Likes || post.Analytics.Views != want.Views {
+			t.Errorf("%s post analytics = %+v, want %+v", post.Platform, post.Analytics, want)
+		}
+	}
+}
@@ -2,16 +2,17 @@ package main
 
 import (
 	"content-creator-agent/agent"
+	"content-creator-agent/bootstrap"
 	"content-creator-agent/memory"
 	"content-creator-agent/models"
 	"content-creator-agent/tools"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -21,9 +22,11 @@ func main() {
 	// CLI Flags
 	configPath := flag.String("config", "config/tech_startup.json", "Path to brand config JSON")
 	useDDG := flag.Bool("ddg", false, "Use DuckDuckGo instead of NewsAPI")
+	dataDir := flag.String("data", "data", "Data directory")
 	syncOnly := flag.Bool("sync", false, "Only sync analytics for past posts")
 	daemon := flag.Bool("daemon", false, "Run in autonomous daemon mode")
 	interval := flag.Duration("interval", 4*time.Hour, "Interval between cycles in daemon mode (e.g. 1h, 30m)")
+	cached := flag.Bool("cached", false, "Reuse cached LLM responses, e.g. while tuning prompts")
 	flag.Parse()
 
 	// 1. Load Brand Config
@@ -39,53 +42,11 @@ func main() {
 
 	// 2. Initialize Tools
 	godotenv.Load()
-	var search tools.SearchTool
-	ddg := tools.NewDuckDuckGoSearch()
-
-	apiKey := os.Getenv("NEWSAPI_KEY")
-	if *useDDG || apiKey == "" {
-		if apiKey == "" && !*useDDG {
-			fmt.Println("WARNING: NEWSAPI_KEY not set. Using DuckDuckGo.")
-		}
-		search = ddg
-	} else {
-		var primary tools.SearchTool
-		if strings.HasPrefix(apiKey, "pub_") {
-			primary = tools.NewNewsDataSearch(apiKey)
-		} else {
-			primary = tools.NewNewsAPISearch(apiKey)
-		}
-		search = tools.NewResilientSearch(primary, ddg)
-	}
-
-	geminiKey := os.Getenv("GEMINI_API_KEY")
-	if geminiKey == "" {
-		log.Fatal("GEMINI_API_KEY environment variable is required.")
-	}
-	llm := tools.NewGeminiClient(geminiKey, "gemini-3-flash-preview")
-	embedding := tools.NewGeminiEmbeddingClient(geminiKey, "gemini-embedding-001")
-
-	// Multi-Social Client
-	social := tools.NewMultiSocialClient()
-	analytics := &tools.MultiAnalyticsFetcher{Fetchers: make(map[string]tools.AnalyticsFetcher)}
-
-	twitterKey := os.Getenv("TWITTER_API_KEY")
-	if twitterKey != "" {
-		tc := tools.NewTwitterClient(twitterKey, os.Getenv("TWITTER_API_SECRET"), os.Getenv("TWITTER_ACCESS_TOKEN"), os.Getenv("TWITTER_ACCESS_SECRET"))
-		social.AddClient("twitter", tc)
-		analytics.Fetchers["twitter"] = &tools.TwitterAnalyticsFetcher{Client: tc}
-	}
-
-	linkedInToken := os.Getenv("LINKEDIN_ACCESS_TOKEN")
-	if linkedInToken != "" {
-		lc := tools.NewLinkedInClient(linkedInToken, os.Getenv("LINKEDIN_PERSON_URN"))
-		social.AddClient("linkedin", lc)
-		analytics.Fetchers["linkedin"] = &tools.LinkedInAnalyticsFetcher{Client: lc}
-	}
-
-	if len(social.Clients) == 0 {
-		social.AddClient("mock", &tools.MockSocialClient{Platform: "Mock"})
+	shared, err := bootstrap.FromEnv(bootstrap.Options{DataDir: *dataDir, UseDDG: *useDDG})
+	if err != nil {
+		log.Fatalf("Failed to initialize tools: %v", err)
 	}
+	defer shared.Close()
 
 	// --- Database Selection ---
 	var store memory.Store
@@ -99,14 +60,18 @@ func main() {
 		store = pgStore
 		fmt.Println("✅ CLI using PostgreSQL database.")
 	} else {
-		store = memory.NewFileStore("data")
+		store = memory.NewFileStore(*dataDir)
 		fmt.Println("📁 CLI using local JSON files.")
 	}
 
-	vector := memory.NewLocalVectorStore(filepath.Join("data", brand.ID, "vectors.json"))
+	vector := memory.NewLocalVectorStore(filepath.Join(*dataDir, brand.ID, "vectors.json"))
+	// Brands fall back to a local embedder whose vocabulary is kept next to their vectors
+	embedding := tools.NewFallbackEmbedding(shared.Embedding, tools.NewLocalEmbedding(filepath.Join(*dataDir, brand.ID, "vocabulary.json")))
 
 	// 3. Initialize Agent
-	creator := agent.NewAgent(brand, search, llm, social, store, vector, embedding, analytics)
+	creator := agent.NewAgent(brand, shared.Search, shared.LLM, shared.Social, store, vector, embedding, shared.Analytics)
+	creator.Providers = shared.Providers
+	creator.Prices = shared.Prices
 
 	// 4. Run Logic
 	if *syncOnly {
@@ -123,7 +88,11 @@ func main() {
 		return
 	}
 
-	if err := creator.Run(); err != nil {
+	ctx := context.Background()
+	if *cached {
+		ctx = tools.WithCache(ctx)
+	}
+	if err := creator.Run(ctx); err != nil {
 		log.Fatalf("Agent run failed: %v", err)
 	}
 }
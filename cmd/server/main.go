@@ -2,16 +2,15 @@ package main
 
 import (
 	"content-creator-agent/api"
+	"content-creator-agent/bootstrap"
 	"content-creator-agent/memory"
 	"content-creator-agent/scheduler"
-	"content-creator-agent/tools"
 	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -24,56 +23,11 @@ func main() {
 	godotenv.Load()
 
 	// --- Initialize shared tools ---
-	geminiKey := os.Getenv("GEMINI_API_KEY")
-	if geminiKey == "" {
-		log.Fatal("GEMINI_API_KEY is required")
-	}
-
-	// Search
-	var search tools.SearchTool
-	ddg := tools.NewDuckDuckGoSearch()
-	newsKey := os.Getenv("NEWSAPI_KEY")
-	if newsKey != "" {
-		var primary tools.SearchTool
-		if strings.HasPrefix(newsKey, "pub_") {
-			primary = tools.NewNewsDataSearch(newsKey)
-		} else {
-			primary = tools.NewNewsAPISearch(newsKey)
-		}
-		search = tools.NewResilientSearch(primary, ddg)
-	} else {
-		search = ddg
-	}
-
-	llm := tools.NewGeminiClient(geminiKey, "gemini-3-flash-preview")
-	embedding := tools.NewGeminiEmbeddingClient(geminiKey, "gemini-embedding-001")
-
-	// Social
-	social := tools.NewMultiSocialClient()
-	analytics := &tools.MultiAnalyticsFetcher{Fetchers: make(map[string]tools.AnalyticsFetcher)}
-
-	twitterKey := os.Getenv("TWITTER_API_KEY")
-	if twitterKey != "" {
-		tc := tools.NewTwitterClient(twitterKey, os.Getenv("TWITTER_API_SECRET"), os.Getenv("TWITTER_ACCESS_TOKEN"), os.Getenv("TWITTER_ACCESS_SECRET"))
-		social.AddClient("twitter", tc)
-		analytics.Fetchers["twitter"] = &tools.TwitterAnalyticsFetcher{Client: tc}
-	}
-
-	liToken := os.Getenv("LINKEDIN_ACCESS_TOKEN")
-	if liToken != "" {
-		lc := tools.NewLinkedInClient(liToken, os.Getenv("LINKEDIN_PERSON_URN"))
-		social.AddClient("linkedin", lc)
-		analytics.Fetchers["linkedin"] = &tools.LinkedInAnalyticsFetcher{Client: lc}
-	}
-
-	// Meta Platforms (Stubs)
-	social.AddClient("instagram", tools.NewInstagramClient())
-	social.AddClient("tiktok", tools.NewTikTokClient())
-	social.AddClient("threads", tools.NewThreadsClient())
-
-	if len(social.Clients) == 0 {
-		social.AddClient("mock", &tools.MockSocialClient{Platform: "Mock"})
+	shared, err := bootstrap.FromEnv(bootstrap.Options{DataDir: *dataDir})
+	if err != nil {
+		log.Fatalf("Failed to initialize tools: %v", err)
 	}
+	defer shared.Close()
 
 	// --- Database Selection ---
 	var store memory.Store
@@ -99,13 +53,14 @@ func main() {
 	}
 
 	// --- Job Queue & Workers ---
+	os.MkdirAll(*dataDir, 0755)
 	queue, err := scheduler.NewSQLiteQueue(filepath.Join(*dataDir, "jobs.db"))
 	if err != nil {
 		log.Fatalf("Failed to initialize job queue: %v", err)
 	}
 	defer queue.Close()
 
-	factory := scheduler.DefaultAgentFactory(store, search, llm, social, embedding, analytics, *dataDir)
+	factory := scheduler.DefaultAgentFactory(store, shared.Search, shared.LLM, shared.Providers, shared.Social, shared.Embedding, shared.Analytics, shared.Prices, *dataDir)
 	worker := scheduler.NewWorker(queue, factory)
 	go worker.Start(context.Background())
 
@@ -117,11 +72,14 @@ func main() {
 		Store:     store,
 		Queue:     queue,
 		JWTSecret: jwtSecret,
-		Search:    search,
-		LLM:       llm,
-		Social:    social,
-		Embedding: embedding,
-		Analytics: analytics,
+		Search:    shared.Search,
+		LLM:       shared.LLM,
+		Providers: shared.Providers,
+		Social:    shared.Social,
+		Embedding: shared.Embedding,
+		Analytics: shared.Analytics,
+		Cache:     shared.Cache,
+		Prices:    shared.Prices,
 		DataDir:   *dataDir,
 	}
 
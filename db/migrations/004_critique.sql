@@ -0,0 +1,3 @@
+-- Store the structured rubric the final draft was approved with
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS critique JSONB;
+ALTER TABLE scheduled_posts ADD COLUMN IF NOT EXISTS critique JSONB;
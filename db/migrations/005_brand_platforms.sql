@@ -0,0 +1,2 @@
+-- Platforms a brand generates variants for (empty means every configured platform)
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS platforms JSONB DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- Long-form X variants are published as threads; keep every tweet ID
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS thread_ids JSONB;
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS x_threads BOOLEAN DEFAULT FALSE;
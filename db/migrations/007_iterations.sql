@@ -0,0 +1,3 @@
+-- Draft/critique history behind each post
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS iterations JSONB;
+ALTER TABLE scheduled_posts ADD COLUMN IF NOT EXISTS iterations JSONB;
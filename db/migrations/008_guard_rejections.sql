@@ -0,0 +1,14 @@
+-- Trends and drafts dropped by the brand guardrails
+CREATE TABLE IF NOT EXISTS guard_rejections (
+    id BIGSERIAL PRIMARY KEY,
+    brand_id TEXT NOT NULL REFERENCES brands(id) ON DELETE CASCADE,
+    run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    stage TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    anti_topic TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    method TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_guard_rejections_brand ON guard_rejections(brand_id, created_at);
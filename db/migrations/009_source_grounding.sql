@@ -0,0 +1,4 @@
+-- Source article each post is grounded in
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS source_url TEXT;
+ALTER TABLE scheduled_posts ADD COLUMN IF NOT EXISTS source_url TEXT;
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS include_source_link BOOLEAN DEFAULT FALSE;
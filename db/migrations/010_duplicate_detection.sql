@@ -0,0 +1,3 @@
+-- Semantic duplicate detection settings per brand
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS duplicate_threshold DOUBLE PRECISION DEFAULT 0.9;
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS duplicate_lookback_days INTEGER DEFAULT 30;
@@ -0,0 +1,3 @@
+-- Per-brand approval threshold and agent pipeline
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS min_score INTEGER DEFAULT 8;
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS pipeline JSONB DEFAULT '[]';
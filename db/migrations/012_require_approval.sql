@@ -0,0 +1,2 @@
+-- Hold automatic cycles for human approval
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS require_approval BOOLEAN DEFAULT FALSE;
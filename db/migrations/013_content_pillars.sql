@@ -0,0 +1,4 @@
+-- Content pillars and the pillar each post was planned for
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS pillars JSONB DEFAULT '[]';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pillar TEXT;
+ALTER TABLE scheduled_posts ADD COLUMN IF NOT EXISTS pillar TEXT;
@@ -0,0 +1,4 @@
+-- Localized variants
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS locales JSONB DEFAULT '[]';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS locale TEXT;
+ALTER TABLE scheduled_posts ADD COLUMN IF NOT EXISTS locale TEXT;
@@ -0,0 +1,16 @@
+-- Persisted traces of agent runs
+CREATE TABLE IF NOT EXISTS run_traces (
+    id TEXT PRIMARY KEY,
+    brand_id TEXT NOT NULL REFERENCES brands(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    steps JSONB DEFAULT '[]',
+    llm_calls JSONB DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_traces_brand ON run_traces(brand_id, started_at);
+
+ALTER TABLE guard_rejections ADD COLUMN IF NOT EXISTS run_id TEXT;
@@ -0,0 +1,3 @@
+-- LLM provider selection per brand and per pipeline step
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS llm_provider TEXT;
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS step_providers JSONB DEFAULT '{}';
@@ -0,0 +1,25 @@
+-- LLM usage ledger and spend budgets
+CREATE TABLE IF NOT EXISTS usage_records (
+    id TEXT PRIMARY KEY,
+    brand_id TEXT NOT NULL, -- No foreign key: spend outlives deleted brands
+    user_id TEXT,
+    run_id TEXT,
+    step TEXT,
+    kind TEXT NOT NULL,
+    provider TEXT,
+    model TEXT,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    cost_usd DOUBLE PRECISION NOT NULL DEFAULT 0,
+    cached BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_records_brand ON usage_records(brand_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_usage_records_user ON usage_records(user_id, created_at);
+
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS daily_budget_usd DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS monthly_budget_usd DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS daily_budget_usd DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS monthly_budget_usd DOUBLE PRECISION DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Per-brand prompt template overrides and the prompt versions behind every post and run
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS prompts JSONB DEFAULT '{}';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS prompt_versions JSONB DEFAULT '{}';
+ALTER TABLE scheduled_posts ADD COLUMN IF NOT EXISTS prompt_versions JSONB DEFAULT '{}';
+ALTER TABLE run_traces ADD COLUMN IF NOT EXISTS prompt_versions JSONB DEFAULT '{}';
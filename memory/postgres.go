@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -37,58 +38,73 @@ func (p *PostgresStore) Close() {
 
 // --- Post Management ---
 
+const postColumns = `id, social_id, brand_id, topic, content, platform, status, views, likes, shares, comments, created_at, updated_at, critique, thread_ids, iterations, source_url, pillar, locale, prompt_versions`
+
+// scanPost reads a row selected with postColumns.
+func scanPost(row pgx.Row) (models.Post, error) {
+	var post models.Post
+	var status string
+	var socialID sql.NullString
+	var critique, threadIDs, iterations, promptVersions []byte
+	var sourceURL, pillar, locale sql.NullString
+	err := row.Scan(
+		&post.ID, &socialID, &post.BrandID, &post.Topic, &post.Content,
+		&post.Platform, &status, &post.Analytics.Views, &post.Analytics.Likes,
+		&post.Analytics.Shares, &post.Analytics.Comments, &post.CreatedAt, &post.UpdatedAt,
+		&critique, &threadIDs, &iterations, &sourceURL, &pillar, &locale, &promptVersions,
+	)
+	if err != nil {
+		return post, err
+	}
+	post.SocialID = socialID.String
+	post.SourceURL = sourceURL.String
+	post.Pillar = pillar.String
+	post.Locale = locale.String
+	post.Status = models.PostStatus(status)
+	json.Unmarshal(critique, &post.Critique)
+	json.Unmarshal(threadIDs, &post.ThreadIDs)
+	json.Unmarshal(iterations, &post.Iterations)
+	json.Unmarshal(promptVersions, &post.PromptVersions)
+	return post, nil
+}
+
 func (p *PostgresStore) SavePost(post models.Post) error {
 	query := `
-		INSERT INTO posts (id, social_id, brand_id, topic, content, platform, status, views, likes, shares, comments, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		INSERT INTO posts (` + postColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	`
+	critiqueJSON, _ := json.Marshal(post.Critique)
+	threadIDsJSON, _ := json.Marshal(post.ThreadIDs)
+	iterationsJSON, _ := json.Marshal(post.Iterations)
+	promptVersionsJSON, _ := json.Marshal(post.PromptVersions)
+
 	_, err := p.pool.Exec(context.Background(), query,
 		post.ID, post.SocialID, post.BrandID, post.Topic, post.Content, post.Platform,
 		string(post.Status), post.Analytics.Views, post.Analytics.Likes,
 		post.Analytics.Shares, post.Analytics.Comments, post.CreatedAt, post.UpdatedAt,
+		critiqueJSON, threadIDsJSON, iterationsJSON, post.SourceURL, post.Pillar, post.Locale, promptVersionsJSON,
 	)
 	return err
 }
 
 func (p *PostgresStore) GetHistory(brandID string) ([]models.Post, error) {
-	query := `SELECT id, social_id, brand_id, topic, content, platform, status, views, likes, shares, comments, created_at, updated_at 
-	          FROM posts WHERE brand_id = $1 ORDER BY created_at DESC`
-	rows, err := p.pool.Query(context.Background(), query, brandID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var post models.Post
-		var status string
-		var socialID sql.NullString
-		err := rows.Scan(
-			&post.ID, &socialID, &post.BrandID, &post.Topic, &post.Content,
-			&post.Platform, &status, &post.Analytics.Views, &post.Analytics.Likes,
-			&post.Analytics.Shares, &post.Analytics.Comments, &post.CreatedAt, &post.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		post.SocialID = socialID.String
-		post.Status = models.PostStatus(status)
-		posts = append(posts, post)
-	}
-	return posts, nil
+	query := `SELECT ` + postColumns + ` FROM posts WHERE brand_id = $1 ORDER BY created_at DESC`
+	return p.queryPosts(query, brandID)
 }
 
 func (p *PostgresStore) GetGlobalHistory(userID string, limit int) ([]models.Post, error) {
-	query := `SELECT p.id, p.social_id, p.brand_id, p.topic, p.content, p.platform, p.status, p.views, p.likes, p.shares, p.comments, p.created_at, p.updated_at 
-	          FROM posts p
-	          JOIN brands b ON p.brand_id = b.id
-	          WHERE b.user_id = $1
-	          ORDER BY p.created_at DESC`
+	query := `SELECT ` + postColumns + `
+	          FROM posts
+	          WHERE brand_id IN (SELECT id FROM brands WHERE user_id = $1)
+	          ORDER BY created_at DESC`
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
-	rows, err := p.pool.Query(context.Background(), query, userID)
+	return p.queryPosts(query, userID)
+}
+
+func (p *PostgresStore) queryPosts(query string, args ...interface{}) ([]models.Post, error) {
+	rows, err := p.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -96,19 +112,10 @@ func (p *PostgresStore) GetGlobalHistory(userID string, limit int) ([]models.Pos
 
 	var posts []models.Post
 	for rows.Next() {
-		var post models.Post
-		var status string
-		var socialID sql.NullString
-		err := rows.Scan(
-			&post.ID, &socialID, &post.BrandID, &post.Topic, &post.Content,
-			&post.Platform, &status, &post.Analytics.Views, &post.Analytics.Likes,
-			&post.Analytics.Shares, &post.Analytics.Comments, &post.CreatedAt, &post.UpdatedAt,
-		)
+		post, err := scanPost(rows)
 		if err != nil {
 			return nil, err
 		}
-		post.SocialID = socialID.String
-		post.Status = models.PostStatus(status)
 		posts = append(posts, post)
 	}
 	return posts, nil
@@ -188,10 +195,36 @@ func (p *PostgresStore) GetBrandPerformance(userID string) ([]models.BrandPerfor
 
 // --- Brand Management ---
 
+const brandColumns = `id, user_id, name, industry, voice, target_audience, topics, anti_topics, schedule_interval_hours, platforms, x_threads, include_source_link, duplicate_threshold, duplicate_lookback_days, min_score, pipeline, require_approval, pillars, locales, llm_provider, step_providers, daily_budget_usd, monthly_budget_usd, prompts`
+
+// scanBrand reads a row selected with brandColumns.
+func scanBrand(row pgx.Row) (models.BrandProfile, error) {
+	var b models.BrandProfile
+	var topics, antiTopics, platforms, pipeline, pillars, locales, stepProviders, prompts []byte
+	var llmProvider sql.NullString
+	var dailyBudget, monthlyBudget sql.NullFloat64
+	err := row.Scan(&b.ID, &b.UserID, &b.Name, &b.Industry, &b.Voice, &b.TargetAudience, &topics, &antiTopics, &b.ScheduleIntervalHours, &platforms, &b.XThreads, &b.IncludeSourceLink, &b.DuplicateThreshold, &b.DuplicateLookbackDays, &b.MinScore, &pipeline, &b.RequireApproval, &pillars, &locales, &llmProvider, &stepProviders, &dailyBudget, &monthlyBudget, &prompts)
+	if err != nil {
+		return b, err
+	}
+	json.Unmarshal(topics, &b.Topics)
+	json.Unmarshal(antiTopics, &b.AntiTopics)
+	json.Unmarshal(platforms, &b.Platforms)
+	json.Unmarshal(pipeline, &b.Pipeline)
+	json.Unmarshal(pillars, &b.Pillars)
+	json.Unmarshal(locales, &b.Locales)
+	json.Unmarshal(stepProviders, &b.StepProviders)
+	json.Unmarshal(prompts, &b.Prompts)
+	b.LLMProvider = llmProvider.String
+	b.DailyBudgetUSD = dailyBudget.Float64
+	b.MonthlyBudgetUSD = monthlyBudget.Float64
+	return b, nil
+}
+
 func (p *PostgresStore) SaveBrand(brand models.BrandProfile, userID string) error {
 	query := `
-		INSERT INTO brands (id, user_id, name, industry, voice, target_audience, topics, anti_topics, schedule_interval_hours)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO brands (` + brandColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			industry = EXCLUDED.industry,
@@ -199,37 +232,51 @@ func (p *PostgresStore) SaveBrand(brand models.BrandProfile, userID string) erro
 			target_audience = EXCLUDED.target_audience,
 			topics = EXCLUDED.topics,
 			anti_topics = EXCLUDED.anti_topics,
-			schedule_interval_hours = EXCLUDED.schedule_interval_hours
+			schedule_interval_hours = EXCLUDED.schedule_interval_hours,
+			platforms = EXCLUDED.platforms,
+			x_threads = EXCLUDED.x_threads,
+			include_source_link = EXCLUDED.include_source_link,
+			duplicate_threshold = EXCLUDED.duplicate_threshold,
+			duplicate_lookback_days = EXCLUDED.duplicate_lookback_days,
+			min_score = EXCLUDED.min_score,
+			pipeline = EXCLUDED.pipeline,
+			require_approval = EXCLUDED.require_approval,
+			pillars = EXCLUDED.pillars,
+			locales = EXCLUDED.locales,
+			llm_provider = EXCLUDED.llm_provider,
+			step_providers = EXCLUDED.step_providers,
+			daily_budget_usd = EXCLUDED.daily_budget_usd,
+			monthly_budget_usd = EXCLUDED.monthly_budget_usd,
+			prompts = EXCLUDED.prompts
 	`
 	topicsJSON, _ := json.Marshal(brand.Topics)
 	antiTopicsJSON, _ := json.Marshal(brand.AntiTopics)
+	platformsJSON, _ := json.Marshal(brand.Platforms)
+	pipelineJSON, _ := json.Marshal(brand.Pipeline)
+	pillarsJSON, _ := json.Marshal(brand.Pillars)
+	localesJSON, _ := json.Marshal(brand.Locales)
+	stepProvidersJSON, _ := json.Marshal(brand.StepProviders)
+	promptsJSON, _ := json.Marshal(brand.Prompts)
 
 	_, err := p.pool.Exec(context.Background(), query,
 		brand.ID, userID, brand.Name, brand.Industry, brand.Voice, brand.TargetAudience, topicsJSON, antiTopicsJSON, brand.ScheduleIntervalHours,
+		platformsJSON, brand.XThreads, brand.IncludeSourceLink, brand.DuplicateThreshold, brand.DuplicateLookbackDays, brand.MinScore, pipelineJSON, brand.RequireApproval, pillarsJSON, localesJSON, brand.LLMProvider, stepProvidersJSON,
+		brand.DailyBudgetUSD, brand.MonthlyBudgetUSD, promptsJSON,
 	)
 	return err
 }
 
 func (p *PostgresStore) GetBrand(id string) (models.BrandProfile, string, error) {
-	query := `SELECT id, user_id, name, industry, voice, target_audience, topics, anti_topics, schedule_interval_hours FROM brands WHERE id = $1`
-	var brand models.BrandProfile
-	var topics, antiTopics []byte
-
-	err := p.pool.QueryRow(context.Background(), query, id).Scan(
-		&brand.ID, &brand.UserID, &brand.Name, &brand.Industry, &brand.Voice, &brand.TargetAudience, &topics, &antiTopics, &brand.ScheduleIntervalHours,
-	)
+	query := `SELECT ` + brandColumns + ` FROM brands WHERE id = $1`
+	brand, err := scanBrand(p.pool.QueryRow(context.Background(), query, id))
 	if err != nil {
 		return brand, "", err
 	}
-
-	json.Unmarshal(topics, &brand.Topics)
-	json.Unmarshal(antiTopics, &brand.AntiTopics)
-
 	return brand, brand.UserID, nil
 }
 
 func (p *PostgresStore) ListBrands(userID string) ([]models.BrandProfile, error) {
-	query := `SELECT id, user_id, name, industry, voice, target_audience, topics, anti_topics, schedule_interval_hours FROM brands WHERE user_id = $1`
+	query := `SELECT ` + brandColumns + ` FROM brands WHERE user_id = $1`
 	rows, err := p.pool.Query(context.Background(), query, userID)
 	if err != nil {
 		return nil, err
@@ -238,21 +285,17 @@ func (p *PostgresStore) ListBrands(userID string) ([]models.BrandProfile, error)
 
 	var brands []models.BrandProfile
 	for rows.Next() {
-		var b models.BrandProfile
-		var topics, antiTopics []byte
-		err := rows.Scan(&b.ID, &b.UserID, &b.Name, &b.Industry, &b.Voice, &b.TargetAudience, &topics, &antiTopics, &b.ScheduleIntervalHours)
+		b, err := scanBrand(rows)
 		if err != nil {
 			return nil, err
 		}
-		json.Unmarshal(topics, &b.Topics)
-		json.Unmarshal(antiTopics, &b.AntiTopics)
 		brands = append(brands, b)
 	}
 	return brands, nil
 }
 
 func (p *PostgresStore) ListAllBrands() ([]models.BrandProfile, error) {
-	query := `SELECT id, user_id, name, industry, voice, target_audience, topics, anti_topics, schedule_interval_hours FROM brands`
+	query := `SELECT ` + brandColumns + ` FROM brands`
 	rows, err := p.pool.Query(context.Background(), query)
 	if err != nil {
 		return nil, err
@@ -261,14 +304,10 @@ func (p *PostgresStore) ListAllBrands() ([]models.BrandProfile, error) {
 
 	var brands []models.BrandProfile
 	for rows.Next() {
-		var b models.BrandProfile
-		var topics, antiTopics []byte
-		err := rows.Scan(&b.ID, &b.UserID, &b.Name, &b.Industry, &b.Voice, &b.TargetAudience, &topics, &antiTopics, &b.ScheduleIntervalHours)
+		b, err := scanBrand(rows)
 		if err != nil {
 			return nil, err
 		}
-		json.Unmarshal(topics, &b.Topics)
-		json.Unmarshal(antiTopics, &b.AntiTopics)
 		brands = append(brands, b)
 	}
 	return brands, nil
@@ -282,26 +321,77 @@ func (p *PostgresStore) DeleteBrand(id string) error {
 
 // --- Calendar & Approval ---
 
+const scheduledPostColumns = `id, brand_id, topic, content, platform, status, scheduled_at, created_at, updated_at, critique, iterations, source_url, pillar, locale, prompt_versions`
+
+// scanScheduledPost reads a row selected with scheduledPostColumns.
+func scanScheduledPost(row pgx.Row) (models.ScheduledPost, error) {
+	var post models.ScheduledPost
+	var status string
+	var critique, iterations, promptVersions []byte
+	var sourceURL, pillar, locale sql.NullString
+	err := row.Scan(&post.ID, &post.BrandID, &post.Topic, &post.Content, &post.Platform, &status, &post.ScheduledAt, &post.CreatedAt, &post.UpdatedAt, &critique, &iterations, &sourceURL, &pillar, &locale, &promptVersions)
+	if err != nil {
+		return post, err
+	}
+	post.SourceURL = sourceURL.String
+	post.Pillar = pillar.String
+	post.Locale = locale.String
+	post.Status = models.PostStatus(status)
+	json.Unmarshal(critique, &post.Critique)
+	json.Unmarshal(iterations, &post.Iterations)
+	json.Unmarshal(promptVersions, &post.PromptVersions)
+	return post, nil
+}
+
 func (p *PostgresStore) SaveScheduledPost(post models.ScheduledPost) error {
 	query := `
-		INSERT INTO scheduled_posts (id, brand_id, topic, content, platform, status, scheduled_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO scheduled_posts (` + scheduledPostColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		ON CONFLICT (id) DO UPDATE SET
 			status = EXCLUDED.status,
 			topic = EXCLUDED.topic,
 			content = EXCLUDED.content,
 			scheduled_at = EXCLUDED.scheduled_at,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			critique = EXCLUDED.critique,
+			iterations = EXCLUDED.iterations,
+			prompt_versions = EXCLUDED.prompt_versions
 	`
+	critiqueJSON, _ := json.Marshal(post.Critique)
+	iterationsJSON, _ := json.Marshal(post.Iterations)
+	promptVersionsJSON, _ := json.Marshal(post.PromptVersions)
+
 	_, err := p.pool.Exec(context.Background(), query,
 		post.ID, post.BrandID, post.Topic, post.Content, post.Platform, string(post.Status), post.ScheduledAt, post.CreatedAt, post.UpdatedAt,
+		critiqueJSON, iterationsJSON, post.SourceURL, post.Pillar, post.Locale, promptVersionsJSON,
 	)
 	return err
 }
 
 func (p *PostgresStore) GetScheduledPosts(brandID string) ([]models.ScheduledPost, error) {
-	query := `SELECT id, brand_id, topic, content, platform, status, scheduled_at, created_at, updated_at FROM scheduled_posts WHERE brand_id = $1 ORDER BY scheduled_at ASC`
-	rows, err := p.pool.Query(context.Background(), query, brandID)
+	query := `SELECT ` + scheduledPostColumns + ` FROM scheduled_posts WHERE brand_id = $1 ORDER BY scheduled_at ASC`
+	return p.queryScheduledPosts(query, brandID)
+}
+
+func (p *PostgresStore) UpdateScheduledPostStatus(postID string, status models.PostStatus) error {
+	query := `UPDATE scheduled_posts SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := p.pool.Exec(context.Background(), query, string(status), time.Now(), postID)
+	return err
+}
+
+func (p *PostgresStore) UpdateScheduledPost(postID string, topic, content string) error {
+	query := `UPDATE scheduled_posts SET topic = $1, content = $2, updated_at = $3 WHERE id = $4`
+	_, err := p.pool.Exec(context.Background(), query, topic, content, time.Now(), postID)
+	return err
+}
+
+func (p *PostgresStore) GetPendingScheduledPosts() ([]models.ScheduledPost, error) {
+	query := `SELECT ` + scheduledPostColumns + ` FROM scheduled_posts WHERE status = $1 AND scheduled_at <= $2`
+	return p.queryScheduledPosts(query, string(models.StatusApproved), time.Now())
+}
+
+func (p *PostgresStore) queryScheduledPosts(query string, args ...interface{}) ([]models.ScheduledPost, error) {
+	rows, err := p.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -309,50 +399,177 @@ func (p *PostgresStore) GetScheduledPosts(brandID string) ([]models.ScheduledPos
 
 	var posts []models.ScheduledPost
 	for rows.Next() {
-		var post models.ScheduledPost
-		var status string
-		err := rows.Scan(&post.ID, &post.BrandID, &post.Topic, &post.Content, &post.Platform, &status, &post.ScheduledAt, &post.CreatedAt, &post.UpdatedAt)
+		post, err := scanScheduledPost(rows)
 		if err != nil {
 			return nil, err
 		}
-		post.Status = models.PostStatus(status)
 		posts = append(posts, post)
 	}
 	return posts, nil
 }
 
-func (p *PostgresStore) UpdateScheduledPostStatus(postID string, status models.PostStatus) error {
-	query := `UPDATE scheduled_posts SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := p.pool.Exec(context.Background(), query, string(status), time.Now(), postID)
+// --- Guardrails ---
+
+func (p *PostgresStore) SaveGuardRejections(brandID string, rejections []models.GuardRejection) error {
+	query := `
+		INSERT INTO guard_rejections (brand_id, run_id, run_at, stage, subject, anti_topic, reason, method, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	for _, r := range rejections {
+		_, err := p.pool.Exec(context.Background(), query,
+			brandID, r.RunID, r.RunAt, r.Stage, r.Subject, r.AntiTopic, r.Reason, r.Method, r.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PostgresStore) GetGuardRejections(brandID string) ([]models.GuardRejection, error) {
+	query := `SELECT brand_id, run_id, run_at, stage, subject, anti_topic, reason, method, created_at
+	          FROM guard_rejections WHERE brand_id = $1 ORDER BY created_at DESC`
+	rows, err := p.pool.Query(context.Background(), query, brandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.GuardRejection
+	for rows.Next() {
+		var r models.GuardRejection
+		var runID sql.NullString
+		if err := rows.Scan(&r.BrandID, &runID, &r.RunAt, &r.Stage, &r.Subject, &r.AntiTopic, &r.Reason, &r.Method, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.RunID = runID.String
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// --- Run Traces ---
+
+func (p *PostgresStore) SaveRunTrace(trace models.RunTrace) error {
+	query := `
+		INSERT INTO run_traces (id, brand_id, kind, status, error, started_at, finished_at, steps, llm_calls, prompt_versions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			error = EXCLUDED.error,
+			finished_at = EXCLUDED.finished_at,
+			steps = EXCLUDED.steps,
+			llm_calls = EXCLUDED.llm_calls,
+			prompt_versions = EXCLUDED.prompt_versions
+	`
+	stepsJSON, _ := json.Marshal(trace.Steps)
+	callsJSON, _ := json.Marshal(trace.LLMCalls)
+	promptVersionsJSON, _ := json.Marshal(trace.PromptVersions)
+
+	_, err := p.pool.Exec(context.Background(), query,
+		trace.ID, trace.BrandID, trace.Kind, trace.Status, trace.Error, trace.StartedAt, trace.FinishedAt, stepsJSON, callsJSON, promptVersionsJSON,
+	)
 	return err
 }
 
-func (p *PostgresStore) UpdateScheduledPost(postID string, topic, content string) error {
-	query := `UPDATE scheduled_posts SET topic = $1, content = $2, updated_at = $3 WHERE id = $4`
-	_, err := p.pool.Exec(context.Background(), query, topic, content, time.Now(), postID)
+func (p *PostgresStore) GetRunTraces(brandID string, limit int) ([]models.RunTrace, error) {
+	query := `SELECT id, brand_id, kind, status, error, started_at, finished_at, steps, jsonb_array_length(llm_calls), prompt_versions
+	          FROM run_traces WHERE brand_id = $1 ORDER BY started_at DESC`
+	args := []interface{}{brandID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+	rows, err := p.pool.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	traces := []models.RunTrace{}
+	for rows.Next() {
+		var t models.RunTrace
+		var steps, promptVersions []byte
+		var callCount sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.BrandID, &t.Kind, &t.Status, &t.Error, &t.StartedAt, &t.FinishedAt, &steps, &callCount, &promptVersions); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(steps, &t.Steps)
+		json.Unmarshal(promptVersions, &t.PromptVersions)
+		t.LLMCallCount = int(callCount.Int64)
+		traces = append(traces, t)
+	}
+	return traces, nil
+}
+
+func (p *PostgresStore) GetRunTrace(brandID, runID string) (*models.RunTrace, error) {
+	query := `SELECT id, brand_id, kind, status, error, started_at, finished_at, steps, llm_calls, prompt_versions
+	          FROM run_traces WHERE brand_id = $1 AND id = $2`
+	var t models.RunTrace
+	var steps, calls, promptVersions []byte
+	err := p.pool.QueryRow(context.Background(), query, brandID, runID).Scan(
+		&t.ID, &t.BrandID, &t.Kind, &t.Status, &t.Error, &t.StartedAt, &t.FinishedAt, &steps, &calls, &promptVersions,
+	)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(steps, &t.Steps)
+	json.Unmarshal(calls, &t.LLMCalls)
+	json.Unmarshal(promptVersions, &t.PromptVersions)
+	t.LLMCallCount = len(t.LLMCalls)
+	return &t, nil
+}
+
+// --- Usage Ledger ---
+
+func (p *PostgresStore) SaveUsage(r models.UsageRecord) error {
+	query := `
+		INSERT INTO usage_records (id, brand_id, user_id, run_id, step, kind, provider, model, prompt_tokens, completion_tokens, total_tokens, cost_usd, cached, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err := p.pool.Exec(context.Background(), query,
+		r.ID, r.BrandID, r.UserID, r.RunID, r.Step, r.Kind, r.Provider, r.Model,
+		r.PromptTokens, r.CompletionTokens, r.TotalTokens, r.CostUSD, r.Cached, r.CreatedAt,
+	)
 	return err
 }
 
-func (p *PostgresStore) GetPendingScheduledPosts() ([]models.ScheduledPost, error) {
-	query := `SELECT id, brand_id, topic, content, platform, status, scheduled_at, created_at, updated_at FROM scheduled_posts WHERE status = $1 AND scheduled_at <= $2`
-	rows, err := p.pool.Query(context.Background(), query, string(models.StatusApproved), time.Now())
+func (p *PostgresStore) GetUsage(brandID, userID string, since time.Time) ([]models.UsageRecord, error) {
+	query := `SELECT id, brand_id, user_id, run_id, step, kind, provider, model, prompt_tokens, completion_tokens, total_tokens, cost_usd, cached, created_at
+	          FROM usage_records WHERE created_at >= $1`
+	args := []interface{}{since}
+	if brandID != "" {
+		args = append(args, brandID)
+		query += fmt.Sprintf(" AND brand_id = $%d", len(args))
+	}
+	if userID != "" {
+		args = append(args, userID)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	query += " ORDER BY created_at ASC"
+
+	rows, err := p.pool.Query(context.Background(), query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var posts []models.ScheduledPost
+	records := []models.UsageRecord{}
 	for rows.Next() {
-		var post models.ScheduledPost
-		var status string
-		err := rows.Scan(&post.ID, &post.BrandID, &post.Topic, &post.Content, &post.Platform, &status, &post.ScheduledAt, &post.CreatedAt, &post.UpdatedAt)
-		if err != nil {
+		var r models.UsageRecord
+		var userIDCol, runID, step, provider, model sql.NullString
+		if err := rows.Scan(&r.ID, &r.BrandID, &userIDCol, &runID, &step, &r.Kind, &provider, &model,
+			&r.PromptTokens, &r.CompletionTokens, &r.TotalTokens, &r.CostUSD, &r.Cached, &r.CreatedAt); err != nil {
 			return nil, err
 		}
-		post.Status = models.PostStatus(status)
-		posts = append(posts, post)
+		r.UserID = userIDCol.String
+		r.RunID = runID.String
+		r.Step = step.String
+		r.Provider = provider.String
+		r.Model = model.String
+		records = append(records, r)
 	}
-	return posts, nil
+	return records, nil
 }
 
 // --- User Management ---
@@ -365,21 +582,39 @@ func (p *PostgresStore) CreateUser(email, passwordHash string) (string, error) {
 }
 
 func (p *PostgresStore) GetUserByEmail(email string) (*models.User, error) {
-	query := `SELECT id, email, password_hash FROM users WHERE email = $1`
+	query := `SELECT id, email, password_hash, daily_budget_usd, monthly_budget_usd FROM users WHERE email = $1`
 	var user models.User
-	err := p.pool.QueryRow(context.Background(), query, email).Scan(&user.ID, &user.Email, &user.PasswordHash)
+	var dailyBudget, monthlyBudget sql.NullFloat64
+	err := p.pool.QueryRow(context.Background(), query, email).Scan(&user.ID, &user.Email, &user.PasswordHash, &dailyBudget, &monthlyBudget)
 	if err != nil {
 		return nil, err
 	}
+	user.DailyBudgetUSD = dailyBudget.Float64
+	user.MonthlyBudgetUSD = monthlyBudget.Float64
 	return &user, nil
 }
 
 func (p *PostgresStore) GetUserByID(id string) (*models.User, error) {
-	query := `SELECT id, email, password_hash FROM users WHERE id = $1`
+	query := `SELECT id, email, password_hash, daily_budget_usd, monthly_budget_usd FROM users WHERE id = $1`
 	var user models.User
-	err := p.pool.QueryRow(context.Background(), query, id).Scan(&user.ID, &user.Email, &user.PasswordHash)
+	var dailyBudget, monthlyBudget sql.NullFloat64
+	err := p.pool.QueryRow(context.Background(), query, id).Scan(&user.ID, &user.Email, &user.PasswordHash, &dailyBudget, &monthlyBudget)
 	if err != nil {
 		return nil, err
 	}
+	user.DailyBudgetUSD = dailyBudget.Float64
+	user.MonthlyBudgetUSD = monthlyBudget.Float64
 	return &user, nil
 }
+
+func (p *PostgresStore) UpdateUserBudget(userID string, dailyUSD, monthlyUSD float64) error {
+	query := `UPDATE users SET daily_budget_usd = $1, monthly_budget_usd = $2 WHERE id = $3`
+	tag, err := p.pool.Exec(context.Background(), query, dailyUSD, monthlyUSD, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
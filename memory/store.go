@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -35,10 +37,24 @@ type Store interface {
 	UpdateScheduledPost(postID string, topic, content string) error
 	GetPendingScheduledPosts() ([]models.ScheduledPost, error) // For the scheduler to publish
 
+	// Guardrails
+	SaveGuardRejections(brandID string, rejections []models.GuardRejection) error
+	GetGuardRejections(brandID string) ([]models.GuardRejection, error) // Newest first
+
+	// Run traces
+	SaveRunTrace(trace models.RunTrace) error
+	GetRunTraces(brandID string, limit int) ([]models.RunTrace, error) // Newest first, without LLM calls
+	GetRunTrace(brandID, runID string) (*models.RunTrace, error)
+
+	// Usage ledger
+	SaveUsage(record models.UsageRecord) error
+	GetUsage(brandID, userID string, since time.Time) ([]models.UsageRecord, error) // Empty IDs do not filter; oldest first
+
 	// User management
 	CreateUser(email, passwordHash string) (string, error)
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByID(id string) (*models.User, error)
+	UpdateUserBudget(userID string, dailyUSD, monthlyUSD float64) error
 }
 
 // FileStore implements Store using JSON files on disk.
@@ -411,7 +427,7 @@ func (f *FileStore) GetPendingScheduledPosts() ([]models.ScheduledPost, error) {
 	for _, b := range brands {
 		posts, _ := f.GetScheduledPosts(b.ID)
 		for _, p := range posts {
-			if p.Status == models.StatusScheduled && p.ScheduledAt.Before(time.Now()) {
+			if p.Status == models.StatusApproved && p.ScheduledAt.Before(time.Now()) {
 				pending = append(pending, p)
 			}
 		}
@@ -419,14 +435,199 @@ func (f *FileStore) GetPendingScheduledPosts() ([]models.ScheduledPost, error) {
 	return pending, nil
 }
 
+// --- Guardrails (FileStore Impl) ---
+
+func (f *FileStore) SaveGuardRejections(brandID string, rejections []models.GuardRejection) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := f.brandPath(brandID)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+
+	guardPath := filepath.Join(path, "guardrails.json")
+	var all []models.GuardRejection
+
+	data, err := os.ReadFile(guardPath)
+	if err == nil {
+		json.Unmarshal(data, &all)
+	}
+
+	all = append(all, rejections...)
+	updatedData, _ := json.MarshalIndent(all, "", "  ")
+	return os.WriteFile(guardPath, updatedData, 0644)
+}
+
+func (f *FileStore) GetGuardRejections(brandID string) ([]models.GuardRejection, error) {
+	guardPath := filepath.Join(f.brandPath(brandID), "guardrails.json")
+	data, err := os.ReadFile(guardPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.GuardRejection{}, nil
+		}
+		return nil, err
+	}
+
+	var all []models.GuardRejection
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	// Stored oldest first; reverse for newest first
+	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+		all[i], all[j] = all[j], all[i]
+	}
+	return all, nil
+}
+
+// --- Run Traces (FileStore Impl) ---
+
+func (f *FileStore) SaveRunTrace(trace models.RunTrace) error {
+	path := filepath.Join(f.brandPath(trace.BrandID), "runs")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(path, trace.ID+".json"), data, 0644)
+}
+
+func (f *FileStore) GetRunTraces(brandID string, limit int) ([]models.RunTrace, error) {
+	entries, err := os.ReadDir(filepath.Join(f.brandPath(brandID), "runs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []models.RunTrace{}, nil
+		}
+		return nil, err
+	}
+
+	traces := []models.RunTrace{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		trace, err := f.GetRunTrace(brandID, strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		trace.LLMCalls = nil
+		traces = append(traces, *trace)
+	}
+
+	sort.Slice(traces, func(i, j int) bool { return traces[i].StartedAt.After(traces[j].StartedAt) })
+	if limit > 0 && len(traces) > limit {
+		traces = traces[:limit]
+	}
+	return traces, nil
+}
+
+func (f *FileStore) GetRunTrace(brandID, runID string) (*models.RunTrace, error) {
+	if runID != filepath.Base(runID) {
+		return nil, fmt.Errorf("invalid run id: %s", runID)
+	}
+	data, err := os.ReadFile(filepath.Join(f.brandPath(brandID), "runs", runID+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var trace models.RunTrace
+	if err := json.Unmarshal(data, &trace); err != nil {
+		return nil, err
+	}
+	return &trace, nil
+}
+
+// --- Usage Ledger (FileStore Impl) ---
+
+// SaveUsage appends to the brand's usage.jsonl. The ledger grows with every
+// call, so it is written as JSON lines rather than rewritten as an array.
+func (f *FileStore) SaveUsage(record models.UsageRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := f.brandPath(record.BrandID)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(path, "usage.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+func (f *FileStore) GetUsage(brandID, userID string, since time.Time) ([]models.UsageRecord, error) {
+	brandIDs := []string{brandID}
+	if brandID == "" {
+		var brands []models.BrandProfile
+		var err error
+		if userID != "" {
+			brands, err = f.ListBrands(userID)
+		} else {
+			brands, err = f.ListAllBrands()
+		}
+		if err != nil {
+			return nil, err
+		}
+		brandIDs = nil
+		for _, b := range brands {
+			brandIDs = append(brandIDs, b.ID)
+		}
+	}
+
+	records := []models.UsageRecord{}
+	for _, id := range brandIDs {
+		data, err := os.ReadFile(filepath.Join(f.brandPath(id), "usage.jsonl"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			var r models.UsageRecord
+			if line == "" || json.Unmarshal([]byte(line), &r) != nil {
+				continue
+			}
+			if r.CreatedAt.Before(since) || (userID != "" && r.UserID != userID) {
+				continue
+			}
+			records = append(records, r)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.Before(records[j].CreatedAt) })
+	return records, nil
+}
+
 // --- User Management (FileStore Impl) ---
 
+// fileUser is a user as stored in users.json. models.User keeps the password
+// hash out of API responses, so the file carries it separately.
+type fileUser struct {
+	models.User
+	PasswordHash string `json:"password_hash"`
+}
+
+func (u fileUser) toUser() *models.User {
+	user := u.User
+	user.PasswordHash = u.PasswordHash
+	return &user
+}
+
 func (f *FileStore) CreateUser(email, passwordHash string) (string, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	usersPath := filepath.Join(f.BaseDir, "users.json")
-	var users []models.User
+	var users []fileUser
 
 	data, err := os.ReadFile(usersPath)
 	if err == nil {
@@ -440,9 +641,8 @@ func (f *FileStore) CreateUser(email, passwordHash string) (string, error) {
 	}
 
 	userID := fmt.Sprintf("u-%d", time.Now().Unix())
-	users = append(users, models.User{
-		ID:           userID,
-		Email:        email,
+	users = append(users, fileUser{
+		User:         models.User{ID: userID, Email: email},
 		PasswordHash: passwordHash,
 	})
 
@@ -459,12 +659,12 @@ func (f *FileStore) GetUserByEmail(email string) (*models.User, error) {
 		return nil, err
 	}
 
-	var users []models.User
+	var users []fileUser
 	json.Unmarshal(data, &users)
 
 	for _, u := range users {
 		if u.Email == email {
-			return &u, nil
+			return u.toUser(), nil
 		}
 	}
 
@@ -478,14 +678,39 @@ func (f *FileStore) GetUserByID(id string) (*models.User, error) {
 		return nil, err
 	}
 
-	var users []models.User
+	var users []fileUser
 	json.Unmarshal(data, &users)
 
 	for _, u := range users {
 		if u.ID == id {
-			return &u, nil
+			return u.toUser(), nil
 		}
 	}
 
 	return nil, fmt.Errorf("user not found")
 }
+
+func (f *FileStore) UpdateUserBudget(userID string, dailyUSD, monthlyUSD float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	usersPath := filepath.Join(f.BaseDir, "users.json")
+	data, err := os.ReadFile(usersPath)
+	if err != nil {
+		return err
+	}
+
+	var users []fileUser
+	json.Unmarshal(data, &users)
+
+	for i := range users {
+		if users[i].ID == userID {
+			users[i].DailyBudgetUSD = dailyUSD
+			users[i].MonthlyBudgetUSD = monthlyUSD
+			updatedData, _ := json.MarshalIndent(users, "", "  ")
+			return os.WriteFile(usersPath, updatedData, 0644)
+		}
+	}
+
+	return fmt.Errorf("user not found")
+}
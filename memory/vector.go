@@ -117,6 +117,7 @@ func (l *LocalVectorStore) UpdateMetadata(id string, metadata map[string]interfa
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	// A post indexed with several embedding models has one record per model
 	found := false
 	for i := range l.records {
 		if l.records[i].ID == id {
@@ -124,7 +125,6 @@ func (l *LocalVectorStore) UpdateMetadata(id string, metadata map[string]interfa
 				l.records[i].Metadata[k] = v
 			}
 			found = true
-			break
 		}
 	}
 
@@ -4,17 +4,69 @@ import "time"
 
 // BrandProfile defines the identity and voice of the company agent.
 type BrandProfile struct {
-	ID                    string   `json:"id"`
-	UserID                string   `json:"user_id"`
-	Name                  string   `json:"name"`
-	Industry              string   `json:"industry"`
-	Voice                 string   `json:"voice"`                   // e.g. "Professional", "Witty"
-	TargetAudience        string   `json:"target_audience"`         // e.g. "CTOs", "Gen Z"
-	Topics                []string `json:"topics"`                  // e.g. ["AI", "Cloud"]
-	AntiTopics            []string `json:"anti_topics"`             // e.g. ["Politics"]
-	ScheduleIntervalHours int      `json:"schedule_interval_hours"` // e.g. 4
+	ID                    string            `json:"id"`
+	UserID                string            `json:"user_id"`
+	Name                  string            `json:"name"`
+	Industry              string            `json:"industry"`
+	Voice                 string            `json:"voice"`                        // e.g. "Professional", "Witty"
+	TargetAudience        string            `json:"target_audience"`              // e.g. "CTOs", "Gen Z"
+	Topics                []string          `json:"topics"`                       // e.g. ["AI", "Cloud"]
+	AntiTopics            []string          `json:"anti_topics"`                  // e.g. ["Politics"]
+	ScheduleIntervalHours int               `json:"schedule_interval_hours"`      // e.g. 4
+	Platforms             []string          `json:"platforms"`                    // e.g. ["twitter", "linkedin"]; empty means all configured
+	XThreads              bool              `json:"x_threads"`                    // Write long-form X variants that are published as threads
+	IncludeSourceLink     bool              `json:"include_source_link"`          // Append the source article URL to every post
+	DuplicateThreshold    float64           `json:"duplicate_threshold"`          // Cosine similarity at which a draft counts as a repeat, e.g. 0.9
+	DuplicateLookbackDays int               `json:"duplicate_lookback_days"`      // How far back duplicate detection looks, e.g. 30
+	MinScore              int               `json:"min_score"`                    // Critic score (1-10) a draft needs to be approved, e.g. 8
+	Pipeline              []string          `json:"pipeline"`                     // Ordered agent steps; empty means the default pipeline
+	RequireApproval       bool              `json:"require_approval"`             // Automatic cycles wait in the calendar for human approval
+	Pillars               []Pillar          `json:"pillars"`                      // Content pillars and their target mix
+	Locales               []string          `json:"locales"`                      // e.g. ["en-US", "es-MX"]; one variant per locale
+	LLMProvider           string            `json:"llm_provider,omitempty"`       // Named provider for every step, e.g. "anthropic"
+	StepProviders         map[string]string `json:"step_providers,omitempty"`     // Provider per pipeline step, e.g. {"evaluate": "openai"}
+	DailyBudgetUSD        float64           `json:"daily_budget_usd,omitempty"`   // LLM spend cap per UTC day; 0 means unlimited
+	MonthlyBudgetUSD      float64           `json:"monthly_budget_usd,omitempty"` // LLM spend cap per UTC calendar month; 0 means unlimited
+	Prompts               map[string]string `json:"prompts,omitempty"`            // Prompt template overrides by name, or a pinned built-in version such as "v1"
 }
 
+// Pillar is a recurring content theme with its target share of the brand's posts.
+type Pillar struct {
+	Name        string `json:"name"`                  // e.g. "educational"
+	Share       int    `json:"share"`                 // Target percentage, e.g. 50
+	Description string `json:"description,omitempty"` // What posts in this pillar look like
+}
+
+// PillarShare compares a pillar's target with what was actually posted.
+type PillarShare struct {
+	Pillar   string  `json:"pillar"`
+	Target   float64 `json:"target"` // Target percentage
+	Actual   float64 `json:"actual"` // Percentage of posted topics
+	Posts    int     `json:"posts"`  // Topics posted in this pillar
+	Likes    int     `json:"likes"`
+	Shares   int     `json:"shares"`
+	Comments int     `json:"comments"`
+}
+
+// PromptPerformance sums the results of the posts written with one version of a prompt template.
+type PromptPerformance struct {
+	Prompt   string  `json:"prompt"`
+	Version  string  `json:"version"`
+	Posts    int     `json:"posts"`
+	Likes    int     `json:"likes"`
+	Shares   int     `json:"shares"`
+	Comments int     `json:"comments"`
+	AvgScore float64 `json:"avg_score"` // Mean critic score of the posts that have one
+}
+
+// Platform identifiers used to route posts to social clients.
+const (
+	PlatformX         = "twitter"
+	PlatformLinkedIn  = "linkedin"
+	PlatformThreads   = "threads"
+	PlatformInstagram = "instagram"
+)
+
 // Trend represents a potential topic discovered during research.
 type Trend struct {
 	Query     string    `json:"query"`
@@ -24,6 +76,119 @@ type Trend struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// GuardRejection records a trend or draft dropped by the brand guardrails.
+type GuardRejection struct {
+	BrandID   string    `json:"brand_id"`
+	RunID     string    `json:"run_id,omitempty"` // Trace of the agent run that produced the rejection
+	RunAt     time.Time `json:"run_at"`           // Start of the agent run that produced the rejection
+	Stage     string    `json:"stage"`            // "trend" or "draft"
+	Subject   string    `json:"subject"`          // Trend title or draft text
+	AntiTopic string    `json:"anti_topic"`       // Anti-topic that was touched
+	Reason    string    `json:"reason"`
+	Method    string    `json:"method"` // "keyword" or "classifier"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Run trace statuses.
+const (
+	RunStatusRunning   = "running"
+	RunStatusSucceeded = "succeeded"
+	RunStatusFailed    = "failed"
+)
+
+// RunTrace is the persisted record of one agent run.
+type RunTrace struct {
+	ID           string      `json:"id"`
+	BrandID      string      `json:"brand_id"`
+	Kind         string      `json:"kind"`   // "run", "plan" or "review"
+	Status       string      `json:"status"` // "running", "succeeded" or "failed"
+	Error        string      `json:"error,omitempty"`
+	StartedAt    time.Time   `json:"started_at"`
+	FinishedAt   time.Time   `json:"finished_at"`
+	Steps        []TraceStep `json:"steps"`
+	LLMCallCount int         `json:"llm_call_count"`
+	LLMCalls     []LLMCall   `json:"llm_calls,omitempty"` // Omitted when listing runs
+
+	PromptVersions map[string]string `json:"prompt_versions,omitempty"` // Version of every prompt template the run rendered
+}
+
+// TraceStep records one execution of a pipeline step.
+type TraceStep struct {
+	Name       string    `json:"name"`
+	Round      int       `json:"round"` // Revision round for drafting steps, starting at 0
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Usage kinds.
+const (
+	UsageKindLLM       = "llm"
+	UsageKindEmbedding = "embedding"
+)
+
+// UsageRecord is the token usage and cost of one LLM or embedding call.
+type UsageRecord struct {
+	ID               string    `json:"id"`
+	BrandID          string    `json:"brand_id"`
+	UserID           string    `json:"user_id,omitempty"`
+	RunID            string    `json:"run_id,omitempty"`
+	Step             string    `json:"step,omitempty"` // Pipeline step; empty outside a run
+	Kind             string    `json:"kind"`           // UsageKindLLM or UsageKindEmbedding
+	Provider         string    `json:"provider,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	Cached           bool      `json:"cached,omitempty"` // Served from the cache at no cost
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// SpendSummary reports LLM spend for the current UTC month against the budgets.
+type SpendSummary struct {
+	BrandID          string             `json:"brand_id,omitempty"`
+	UserID           string             `json:"user_id,omitempty"`
+	TodayUSD         float64            `json:"today_usd"`
+	MonthUSD         float64            `json:"month_usd"`
+	DailyBudgetUSD   float64            `json:"daily_budget_usd"`
+	MonthlyBudgetUSD float64            `json:"monthly_budget_usd"`
+	OverBudget       bool               `json:"over_budget"`
+	Calls            int                `json:"calls"`
+	Tokens           int                `json:"tokens"`
+	ByModel          map[string]float64 `json:"by_model"`
+	ByStep           map[string]float64 `json:"by_step"`
+	ByBrand          map[string]float64 `json:"by_brand,omitempty"`
+	Days             []DailySpend       `json:"days"`
+	UnpricedModels   []string           `json:"unpriced_models,omitempty"` // Models missing from the price table, counted as free`
+}
+
+// DailySpend is the spend of one UTC day.
+type DailySpend struct {
+	Date    string  `json:"date"` // YYYY-MM-DD
+	CostUSD float64 `json:"cost_usd"`
+	Tokens  int     `json:"tokens"`
+}
+
+// LLMCall records one request to the language model.
+type LLMCall struct {
+	Step             string    `json:"step"`               // Pipeline step that made the call
+	Provider         string    `json:"provider,omitempty"` // Named provider, empty for the default LLM
+	Model            string    `json:"model,omitempty"`
+	Prompt           string    `json:"prompt,omitempty"` // Template and version, e.g. "generate@v1"
+	SystemPrompt     string    `json:"system_prompt"`
+	UserPrompt       string    `json:"user_prompt"`
+	Response         string    `json:"response"` // Raw response text
+	Error            string    `json:"error,omitempty"`
+	FinishReason     string    `json:"finish_reason,omitempty"`
+	Cached           bool      `json:"cached,omitempty"` // Served from the response cache
+	LatencyMS        int64     `json:"latency_ms"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	StartedAt        time.Time `json:"started_at"`
+}
+
 // PostStatus defines the lifecycle of a post.
 type PostStatus string
 
@@ -37,29 +202,116 @@ const (
 )
 
 type ScheduledPost struct {
-	ID          string     `json:"id"`
-	BrandID     string     `json:"brand_id"`
-	Topic       string     `json:"topic"`
-	Content     string     `json:"content"`
-	Platform    string     `json:"platform"`
-	Status      PostStatus `json:"status"`
-	ScheduledAt time.Time  `json:"scheduled_at"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          string      `json:"id"`
+	BrandID     string      `json:"brand_id"`
+	Topic       string      `json:"topic"`
+	Content     string      `json:"content"`
+	SourceURL   string      `json:"source_url,omitempty"`
+	Pillar      string      `json:"pillar,omitempty"`
+	Platform    string      `json:"platform"`
+	Locale      string      `json:"locale,omitempty"`
+	Status      PostStatus  `json:"status"`
+	Critique    *Critique   `json:"critique,omitempty"`
+	Iterations  []Iteration `json:"iterations,omitempty"`
+	ScheduledAt time.Time   `json:"scheduled_at"`
+
+	PromptVersions map[string]string `json:"prompt_versions,omitempty"` // Prompt template versions the post was written with
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
 // Post represents a piece of content generated by the agent.
 type Post struct {
-	ID        string     `json:"id"`
-	SocialID  string     `json:"social_id"` // Platform-specific ID (Tweet ID, LinkedIn URN)
-	BrandID   string     `json:"brand_id"`
-	Topic     string     `json:"topic"`
-	Content   string     `json:"content"`
-	Platform  string     `json:"platform"` // e.g. "twitter", "linkedin"
-	Status    PostStatus `json:"status"`
-	Analytics Analytics  `json:"analytics"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID         string      `json:"id"`
+	SocialID   string      `json:"social_id"`            // Platform-specific ID (Tweet ID, LinkedIn URN)
+	ThreadIDs  []string    `json:"thread_ids,omitempty"` // Every tweet ID when posted as an X thread; on a failed thread, the tweets rollback could not delete
+	BrandID    string      `json:"brand_id"`
+	Topic      string      `json:"topic"`
+	Content    string      `json:"content"`
+	SourceURL  string      `json:"source_url,omitempty"` // Article the post is grounded in
+	Pillar     string      `json:"pillar,omitempty"`     // Content pillar the topic was planned for
+	Platform   string      `json:"platform"`             // e.g. "twitter", "linkedin"
+	Locale     string      `json:"locale,omitempty"`     // e.g. "es-MX"; empty for brands without locales
+	Status     PostStatus  `json:"status"`
+	Critique   *Critique   `json:"critique,omitempty"`   // Rubric the final draft was approved with
+	Iterations []Iteration `json:"iterations,omitempty"` // Every draft and critique that led to the final text
+	Analytics  Analytics   `json:"analytics"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+
+	PromptVersions map[string]string `json:"prompt_versions,omitempty"` // Prompt template versions the post was written with, e.g. {"generate": "v1"}
+}
+
+// ContentPlan is the planner's structured choice of what to write about.
+type ContentPlan struct {
+	Topic       string `json:"topic"`
+	Rationale   string `json:"rationale"`              // Why this topic is worth posting about now
+	SourceIndex int    `json:"source_index"`           // Index of the chosen trend in the researched list
+	SourceTrend string `json:"source_trend,omitempty"` // Title of the trend that inspired the topic
+	Source      *Trend `json:"source,omitempty"`       // The trend the post must stay grounded in
+	Pillar      string `json:"pillar,omitempty"`       // Content pillar the topic belongs to
+}
+
+// SourceURL returns the URL of the plan's source trend, if any.
+func (p ContentPlan) SourceURL() string {
+	if p.Source == nil {
+		return ""
+	}
+	return p.Source.URL
+}
+
+// Rubric scores a draft from 1 to 10 on each quality dimension.
+type Rubric struct {
+	Voice    int `json:"voice"`
+	Accuracy int `json:"accuracy"`
+	Hook     int `json:"hook"`
+	Clarity  int `json:"clarity"`
+	CTA      int `json:"cta"`
+}
+
+// Overall returns the rounded mean of all rubric dimensions.
+func (r Rubric) Overall() int {
+	sum := r.Voice + r.Accuracy + r.Hook + r.Clarity + r.CTA
+	return (sum*2 + 5) / 10
+}
+
+// Critique is the critic's structured evaluation of a draft.
+type Critique struct {
+	Feedback string `json:"feedback"`
+	Scores   Rubric `json:"scores"`
+	Score    int    `json:"score"`              // Overall score derived from Scores
+	Language string `json:"language,omitempty"` // Language the critic detected, for localized variants
+}
+
+// Iteration records one draft of a post and how it was judged.
+type Iteration struct {
+	Draft    string    `json:"draft"`
+	Critique *Critique `json:"critique,omitempty"` // nil when the draft was rejected before evaluation
+	Issue    string    `json:"issue,omitempty"`    // Why the draft was rejected without evaluation
+}
+
+// RunReport describes everything a dry run produced. Nothing in it was published or saved.
+type RunReport struct {
+	BrandID    string           `json:"brand_id"`
+	RunID      string           `json:"run_id"`
+	RunAt      time.Time        `json:"run_at"`
+	Trends     []Trend          `json:"trends"`               // Trends that survived research and the guardrails
+	Plan       *ContentPlan     `json:"plan,omitempty"`       // The chosen topic
+	Variants   []VariantReport  `json:"variants"`             // One entry per platform
+	Rejections []GuardRejection `json:"rejections,omitempty"` // Guardrail rejections the run would have recorded
+	Error      string           `json:"error,omitempty"`      // Why the run stopped early, if it did
+}
+
+// VariantReport is the outcome of drafting one platform variant during a dry run.
+type VariantReport struct {
+	Platform   string      `json:"platform"`
+	Locale     string      `json:"locale,omitempty"`
+	Content    string      `json:"content"`              // The final draft
+	Approved   bool        `json:"approved"`             // Whether the final draft would have been published
+	Blocked    bool        `json:"blocked,omitempty"`    // Rejected by the guardrails
+	Duplicate  bool        `json:"duplicate,omitempty"`  // Too similar to a recent post
+	Critique   *Critique   `json:"critique,omitempty"`   // Evaluation of the final draft
+	Iterations []Iteration `json:"iterations,omitempty"` // Every draft with its critique or rejection reason
 }
 
 // Analytics holds performance data for a post.
@@ -90,9 +342,11 @@ type BrandPerformance struct {
 
 // User represents a system user.
 type User struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"-"`
+	ID               string  `json:"id"`
+	Email            string  `json:"email"`
+	PasswordHash     string  `json:"-"`
+	DailyBudgetUSD   float64 `json:"daily_budget_usd,omitempty"`   // LLM spend cap across all brands per UTC day; 0 means unlimited
+	MonthlyBudgetUSD float64 `json:"monthly_budget_usd,omitempty"` // Same, per UTC calendar month
 }
 
 // AgentState tracks the current context of the agent loop.
@@ -16,6 +16,9 @@ const (
 	JobTypePublish JobType = "publish" // Specific post publication
 )
 
+// PayloadAutomatic marks run jobs enqueued by the scheduler rather than by a user.
+const PayloadAutomatic = "auto"
+
 type JobStatus string
 
 const (
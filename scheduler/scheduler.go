@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"content-creator-agent/agent"
 	"content-creator-agent/memory"
 	"content-creator-agent/models"
 	"content-creator-agent/tools/logger"
@@ -45,7 +46,7 @@ func (s *Scheduler) SyncAllBrands() {
 	}
 
 	for _, b := range brands {
-		s.EnsureScheduled(b.ID, b.ScheduleIntervalHours)
+		s.EnsureScheduled(b)
 	}
 }
 
@@ -67,7 +68,9 @@ func (s *Scheduler) CheckScheduledPosts() {
 }
 
 // EnsureScheduled checks if a brand needs a new job and enqueues it.
-func (s *Scheduler) EnsureScheduled(brandID string, intervalHours int) {
+func (s *Scheduler) EnsureScheduled(brand models.BrandProfile) {
+	brandID := brand.ID
+	intervalHours := brand.ScheduleIntervalHours
 	if intervalHours <= 0 {
 		intervalHours = 4 // Default
 	}
@@ -82,13 +85,31 @@ func (s *Scheduler) EnsureScheduled(brandID string, intervalHours int) {
 		return
 	}
 
+	if err := agent.CheckBudget(s.Store, brand); err != nil {
+		logger.GlobalBuffer.Warn("⏸ Not scheduling brand %s: %v", brandID, err)
+		return
+	}
+
 	// Find when the next run should be.
 	// We'll check the latest post time.
 	history, err := s.Store.GetHistory(brandID)
-	var nextRunDelay time.Duration
-
+	var lastPost time.Time
 	if err == nil && len(history) > 0 {
-		lastPost := history[0].CreatedAt
+		lastPost = history[0].CreatedAt
+	}
+	// Cycles that wait for approval only leave drafts in the calendar.
+	if brand.RequireApproval {
+		if drafts, err := s.Store.GetScheduledPosts(brandID); err == nil {
+			for _, d := range drafts {
+				if d.CreatedAt.After(lastPost) {
+					lastPost = d.CreatedAt
+				}
+			}
+		}
+	}
+
+	var nextRunDelay time.Duration
+	if !lastPost.IsZero() {
 		nextRunAt := lastPost.Add(time.Duration(intervalHours) * time.Hour)
 		nextRunDelay = time.Until(nextRunAt)
 		if nextRunDelay < 0 {
@@ -100,5 +121,5 @@ func (s *Scheduler) EnsureScheduled(brandID string, intervalHours int) {
 	}
 
 	logger.GlobalBuffer.Info("⏰ Scheduling next run for brand %s in %v", brandID, nextRunDelay)
-	s.Queue.Enqueue(brandID, JobTypeRun, nextRunDelay, "")
+	s.Queue.Enqueue(brandID, JobTypeRun, nextRunDelay, PayloadAutomatic)
 }
@@ -15,9 +15,13 @@ import (
 // AgentFactory creates a new agent for a specific brand.
 type AgentFactory func(brandID string) (*agent.Agent, error)
 
+// DefaultJobTimeout bounds how long a single job may run before its LLM calls are cancelled.
+const DefaultJobTimeout = 15 * time.Minute
+
 type Worker struct {
 	Queue        Queue
 	AgentFactory AgentFactory
+	JobTimeout   time.Duration
 	Quit         chan bool
 }
 
@@ -25,6 +29,7 @@ func NewWorker(q Queue, factory AgentFactory) *Worker {
 	return &Worker{
 		Queue:        q,
 		AgentFactory: factory,
+		JobTimeout:   DefaultJobTimeout,
 		Quit:         make(chan bool),
 	}
 }
@@ -52,14 +57,22 @@ func (w *Worker) Start(ctx context.Context) {
 				continue
 			}
 
-			w.Process(job)
+			w.Process(ctx, job)
 		}
 	}
 }
 
-func (w *Worker) Process(job *Job) {
+// Process runs a single job. Cancelling ctx, or exceeding the job timeout,
+// aborts the job's in-flight LLM calls.
+func (w *Worker) Process(ctx context.Context, job *Job) {
 	logger.GlobalBuffer.Info("🚀 Processing job %d (Brand: %s, Type: %s)", job.ID, job.BrandID, job.Type)
 
+	if w.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.JobTimeout)
+		defer cancel()
+	}
+
 	agentInstance, err := w.AgentFactory(job.BrandID)
 	if err != nil {
 		logger.GlobalBuffer.Error("Worker failed to create agent for brand %s: %v", job.BrandID, err)
@@ -70,11 +83,15 @@ func (w *Worker) Process(job *Job) {
 	var runErr error
 	switch job.Type {
 	case JobTypeRun:
-		runErr = agentInstance.Run()
+		if job.Payload == PayloadAutomatic && agentInstance.Brand.RequireApproval {
+			runErr = agentInstance.RunForReview(ctx)
+		} else {
+			runErr = agentInstance.Run(ctx)
+		}
 	case JobTypeSync:
 		runErr = agentInstance.SyncAnalytics()
 	case JobTypePlan:
-		runErr = agentInstance.PlanBatch(5) // Default to 5 posts for now
+		runErr = agentInstance.PlanBatch(ctx, 5) // Default to 5 posts for now
 	case JobTypePublish:
 		// Payload contains the ScheduledPostID
 		posts, err := agentInstance.Store.GetScheduledPosts(job.BrandID)
@@ -110,7 +127,7 @@ func (w *Worker) Process(job *Job) {
 }
 
 // DefaultAgentFactory helper to create the factory.
-func DefaultAgentFactory(store memory.Store, search tools.SearchTool, llm tools.LLMTool, social tools.SocialClient, embedding tools.EmbeddingTool, analytics tools.AnalyticsFetcher, dataDir string) AgentFactory {
+func DefaultAgentFactory(store memory.Store, search tools.SearchTool, llm tools.LLMTool, providers map[string]tools.LLMTool, social tools.SocialClient, embedding tools.EmbeddingTool, analytics tools.AnalyticsFetcher, prices tools.PriceTable, dataDir string) AgentFactory {
 	return func(brandID string) (*agent.Agent, error) {
 		brand, _, err := store.GetBrand(brandID)
 		if err != nil {
@@ -118,6 +135,12 @@ func DefaultAgentFactory(store memory.Store, search tools.SearchTool, llm tools.
 		}
 
 		vectorStore := memory.NewLocalVectorStore(filepath.Join(dataDir, brandID, "vectors.json"))
-		return agent.NewAgent(brand, search, llm, social, store, vectorStore, embedding, analytics), nil
+		local := tools.NewLocalEmbedding(filepath.Join(dataDir, brandID, "vocabulary.json"))
+		a := agent.NewAgent(brand, search, llm, social, store, vectorStore, tools.NewFallbackEmbedding(embedding, local), analytics)
+		a.Providers = providers
+		if prices != nil {
+			a.Prices = prices
+		}
+		return a, nil
 	}
 }
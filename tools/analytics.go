@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -18,12 +19,18 @@ type TwitterAnalyticsFetcher struct {
 	Client *TwitterClient
 }
 
+// Fetch returns the post's public metrics. For threads the metrics of every
+// tweet in the thread are added together.
 func (t *TwitterAnalyticsFetcher) Fetch(post *models.Post) (models.Analytics, error) {
-	if post.SocialID == "" {
-		return models.Analytics{}, fmt.Errorf("post has no social ID")
+	ids := post.ThreadIDs
+	if len(ids) == 0 {
+		if post.SocialID == "" {
+			return models.Analytics{}, fmt.Errorf("post has no social ID")
+		}
+		ids = []string{post.SocialID}
 	}
 
-	apiURL := fmt.Sprintf("https://api.twitter.com/2/tweets/%s?tweet.fields=public_metrics", post.SocialID)
+	apiURL := fmt.Sprintf("https://api.twitter.com/2/tweets?ids=%s&tweet.fields=public_metrics", strings.Join(ids, ","))
 	req, _ := http.NewRequest("GET", apiURL, nil)
 	authHeader := t.Client.generateOAuthHeader("GET", apiURL, nil)
 	req.Header.Set("Authorization", authHeader)
@@ -36,7 +43,7 @@ func (t *TwitterAnalyticsFetcher) Fetch(post *models.Post) (models.Analytics, er
 	defer resp.Body.Close()
 
 	var result struct {
-		Data struct {
+		Data []struct {
 			PublicMetrics struct {
 				RetweetCount int `json:"retweet_count"`
 				ReplyCount   int `json:"reply_count"`
@@ -50,12 +57,17 @@ func (t *TwitterAnalyticsFetcher) Fetch(post *models.Post) (models.Analytics, er
 		return models.Analytics{}, err
 	}
 
-	return models.Analytics{
-		Likes:    result.Data.PublicMetrics.LikeCount,
-		Shares:   result.Data.PublicMetrics.RetweetCount + result.Data.PublicMetrics.QuoteCount,
-		Comments: result.Data.PublicMetrics.ReplyCount,
-		Views:    0, // X API v2 basic metrics don't always include impressions for all tiers
-	}, nil
+	var total models.Analytics // X API v2 basic metrics don't always include impressions for all tiers
+	for _, tweet := range result.Data {
+		total.Likes += tweet.PublicMetrics.LikeCount
+		total.Shares += tweet.PublicMetrics.RetweetCount + tweet.PublicMetrics.QuoteCount
+		total.Comments += tweet.PublicMetrics.ReplyCount
+	}
+	// Each tweet after the first is our own reply to the previous one.
+	if len(result.Data) > 1 {
+		total.Comments = max(0, total.Comments-(len(result.Data)-1))
+	}
+	return total, nil
 }
 
 // LinkedInAnalyticsFetcher pulls metrics from LinkedIn.
@@ -98,7 +110,14 @@ type MultiAnalyticsFetcher struct {
 }
 
 func (m *MultiAnalyticsFetcher) Fetch(post *models.Post) (models.Analytics, error) {
-	fetcher, ok := m.Fetchers[post.Platform]
+	fetcher, ok := m.Fetchers[RouteKey(post.Platform, post.Locale)]
+	if !ok {
+		fetcher, ok = m.Fetchers[post.Platform]
+	}
+	if !ok {
+		// Like MultiSocialClient, a registered mock stands in for every platform.
+		fetcher, ok = m.Fetchers["mock"]
+	}
 	if !ok {
 		return models.Analytics{}, fmt.Errorf("no fetcher for platform: %s", post.Platform)
 	}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AnthropicClient implements LLMTool using the Anthropic Messages API.
+type AnthropicClient struct {
+	APIKey    string
+	Model     string
+	BaseURL   string // e.g. "https://api.anthropic.com/v1"
+	Version   string // Value of the anthropic-version header
+	MaxTokens int    // Default upper bound on generated tokens per call
+	Client    *http.Client
+}
+
+func NewAnthropicClient(apiKey, model string) *AnthropicClient {
+	if model == "" {
+		model = "claude-sonnet-4-5"
+	}
+	return &AnthropicClient{
+		APIKey:    apiKey,
+		Model:     model,
+		BaseURL:   "https://api.anthropic.com/v1",
+		Version:   "2023-06-01",
+		MaxTokens: 4096,
+		Client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+type anthropicRequest struct {
+	Model       string       `json:"model"`
+	MaxTokens   int          `json:"max_tokens"`
+	System      string       `json:"system,omitempty"`
+	Messages    []LLMMessage `json:"messages"`
+	Temperature *float64     `json:"temperature,omitempty"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Generate implements LLMTool. The Messages API has no JSON mode, so JSON
+// requests rely on the prompt. Rate-limit (429) and overload (529) responses
+// are returned as a retryable *APIError.
+func (c *AnthropicClient) Generate(ctx context.Context, r LLMRequest) (*LLMResponse, error) {
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("anthropic api key is required")
+	}
+
+	reqBody := anthropicRequest{
+		Model:       c.Model,
+		MaxTokens:   c.MaxTokens,
+		System:      r.System,
+		Messages:    r.Messages,
+		Temperature: r.Temperature,
+	}
+	if r.MaxTokens > 0 {
+		reqBody.MaxTokens = r.MaxTokens
+	}
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.BaseURL, "/")+"/messages", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("anthropic-version", c.Version)
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("anthropic", resp)
+	}
+
+	var aResp anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&aResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range aResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("empty response from anthropic (stop reason %q)", aResp.StopReason)
+	}
+
+	model := aResp.Model
+	if model == "" {
+		model = c.Model
+	}
+	return &LLMResponse{
+		Text:         text.String(),
+		Model:        model,
+		FinishReason: aResp.StopReason,
+		Usage: TokenUsage{
+			PromptTokens:     aResp.Usage.InputTokens,
+			CompletionTokens: aResp.Usage.OutputTokens,
+			TotalTokens:      aResp.Usage.InputTokens + aResp.Usage.OutputTokens,
+		},
+	}, nil
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// anthropicStandIn serves /v1/messages with handle and records the last request.
+func anthropicStandIn(t *testing.T, handle func(w http.ResponseWriter, req anthropicRequest)) (*AnthropicClient, *http.Request) {
+	t.Helper()
+	var last http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r
+		if r.URL.Path != "/v1/messages" {
+			http.NotFound(w, r)
+			return
+		}
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handle(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	client := NewAnthropicClient("sk-ant-test", "claude-test")
+	client.BaseURL = srv.URL + "/v1"
+	return client, &last
+}
+
+func TestAnthropicGenerate(t *testing.T) {
+	temp := 0.3
+	var got anthropicRequest
+	client, last := anthropicStandIn(t, func(w http.ResponseWriter, req anthropicRequest) {
+		got = req
+		fmt.Fprint(w, `{
+			"model": "claude-test-20250101",
+			"content": [
+				{"type": "text", "text": "Hello "},
+				{"type": "tool_use", "id": "t1", "name": "lookup", "input": {}},
+				{"type": "text", "text": "there"}
+			],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 21, "output_tokens": 4}
+		}`)
+	})
+
+	req := Prompt("Be brief.", "Say hello")
+	req.Temperature = &temp
+	res, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key, version := last.Header.Get("x-api-key"), last.Header.Get("anthropic-version"); key != "sk-ant-test" || version != "2023-06-01" {
+		t.Errorf("x-api-key = %q, anthropic-version = %q", key, version)
+	}
+	if got.Model != "claude-test" || got.MaxTokens != 4096 || got.Temperature == nil || *got.Temperature != 0.3 {
+		t.Errorf("request options not sent: %+v", got)
+	}
+	if got.System != "Be brief." || len(got.Messages) != 1 || got.Messages[0].Role != RoleUser || got.Messages[0].Content != "Say hello" {
+		t.Errorf("system = %q, messages = %+v; want the system prompt outside the messages", got.System, got.Messages)
+	}
+
+	if res.Text != "Hello there" || res.Model != "claude-test-20250101" || res.FinishReason != "end_turn" {
+		t.Errorf("response = %+v", res)
+	}
+	if res.Usage != (TokenUsage{PromptTokens: 21, CompletionTokens: 4, TotalTokens: 25}) {
+		t.Errorf("usage = %+v", res.Usage)
+	}
+}
+
+func TestAnthropicGenerateOptions(t *testing.T) {
+	var got anthropicRequest
+	client, _ := anthropicStandIn(t, func(w http.ResponseWriter, req anthropicRequest) {
+		got = req
+		fmt.Fprint(w, `{"content": [{"type": "text", "text": "Once upon"}], "stop_reason": "max_tokens", "usage": {"input_tokens": 5, "output_tokens": 2}}`)
+	})
+
+	req := Prompt("", "Tell a story")
+	req.MaxTokens = 2
+	res, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxTokens != 2 || got.System != "" || got.Temperature != nil {
+		t.Errorf("request = %+v, want the per-call max_tokens and no system prompt", got)
+	}
+	if res.FinishReason != "max_tokens" || res.Model != "claude-test" {
+		t.Errorf("response = %+v, want the truncation reported and the configured model", res)
+	}
+}
+
+func TestAnthropicGenerateEmpty(t *testing.T) {
+	client, _ := anthropicStandIn(t, func(w http.ResponseWriter, req anthropicRequest) {
+		fmt.Fprint(w, `{"content": [], "stop_reason": "refusal"}`)
+	})
+	if _, err := client.Generate(context.Background(), Prompt("", "x")); err == nil {
+		t.Error("empty content accepted")
+	}
+
+	client.APIKey = ""
+	if _, err := client.Generate(context.Background(), Prompt("", "x")); err == nil {
+		t.Error("request without an API key sent")
+	}
+}
+
+func TestAnthropicErrors(t *testing.T) {
+	retryAt := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		status     int
+		retryAfter string
+		retryable  bool
+		minWait    time.Duration
+		maxWait    time.Duration
+	}{
+		{StatusOverloaded, "7", true, 7 * time.Second, 7 * time.Second},
+		{http.StatusTooManyRequests, "", true, 0, 0},
+		{http.StatusTooManyRequests, retryAt, true, 80 * time.Second, 90 * time.Second},
+		{http.StatusInternalServerError, "", true, 0, 0},
+		{http.StatusBadRequest, "", false, 0, 0},
+		{http.StatusUnauthorized, "", false, 0, 0},
+	}
+	for _, tt := range tests {
+		client, _ := anthropicStandIn(t, func(w http.ResponseWriter, req anthropicRequest) {
+			if tt.retryAfter != "" {
+				w.Header().Set("Retry-After", tt.retryAfter)
+			}
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, `{"type": "error", "error": {"type": "overloaded_error"}}`)
+		})
+
+		_, err := client.Generate(context.Background(), Prompt("", "x"))
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%d: err = %v, want an *APIError", tt.status, err)
+			continue
+		}
+		if apiErr.Provider != "anthropic" || apiErr.StatusCode != tt.status || apiErr.Retryable() != tt.retryable {
+			t.Errorf("%d: %+v, retryable %v; want retryable %v", tt.status, apiErr, apiErr.Retryable(), tt.retryable)
+		}
+		if apiErr.RetryAfter < tt.minWait || apiErr.RetryAfter > tt.maxWait {
+			t.Errorf("%d with Retry-After %q: waits %v, want %v-%v", tt.status, tt.retryAfter, apiErr.RetryAfter, tt.minWait, tt.maxWait)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// DefaultCacheTTL is how long cached LLM responses are served. Embeddings never expire.
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache kinds, stored with every entry.
+const (
+	cacheKindLLM       = "llm"
+	cacheKindEmbedding = "embedding"
+)
+
+// Cache is a content-addressed SQLite store for LLM responses and embeddings.
+// Entries are keyed by a hash of the provider, model and the full request.
+type Cache struct {
+	TTL time.Duration // Lifetime of LLM responses; zero or less keeps them forever
+
+	db              *sql.DB
+	llmHits         atomic.Int64
+	llmMisses       atomic.Int64
+	embeddingHits   atomic.Int64
+	embeddingMisses atomic.Int64
+}
+
+// CacheStats reports cache usage since the process started and the number of live entries.
+type CacheStats struct {
+	Enabled          bool  `json:"enabled"`
+	LLMHits          int64 `json:"llm_hits"`
+	LLMMisses        int64 `json:"llm_misses"`
+	EmbeddingHits    int64 `json:"embedding_hits"`
+	EmbeddingMisses  int64 `json:"embedding_misses"`
+	LLMEntries       int   `json:"llm_entries"`
+	EmbeddingEntries int   `json:"embedding_entries"`
+}
+
+// OpenCache opens or creates the cache database at dbPath and drops expired entries.
+func OpenCache(dbPath string) (*Cache, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // The worker and the API share the file
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS cache_entries (
+		key TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		value BLOB NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_cache_entries_expires ON cache_entries(expires_at);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(`DELETE FROM cache_entries WHERE expires_at IS NOT NULL AND expires_at <= ?`, time.Now().Unix()); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Cache{TTL: DefaultCacheTTL, db: db}, nil
+}
+
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// Stats returns the hit and miss counters and the number of live entries.
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{
+		Enabled:         true,
+		LLMHits:         c.llmHits.Load(),
+		LLMMisses:       c.llmMisses.Load(),
+		EmbeddingHits:   c.embeddingHits.Load(),
+		EmbeddingMisses: c.embeddingMisses.Load(),
+	}
+	query := `SELECT kind, COUNT(*) FROM cache_entries WHERE expires_at IS NULL OR expires_at > ? GROUP BY kind`
+	rows, err := c.db.Query(query, time.Now().Unix())
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind string
+		var count int
+		if err := rows.Scan(&kind, &count); err != nil {
+			return stats, err
+		}
+		switch kind {
+		case cacheKindLLM:
+			stats.LLMEntries = count
+		case cacheKindEmbedding:
+			stats.EmbeddingEntries = count
+		}
+	}
+	return stats, rows.Err()
+}
+
+// get decodes a live entry into out. It reports false on a miss.
+func (c *Cache) get(key string, out interface{}) bool {
+	var value []byte
+	query := `SELECT value FROM cache_entries WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)`
+	if err := c.db.QueryRow(query, key, time.Now().Unix()).Scan(&value); err != nil {
+		return false
+	}
+	return json.Unmarshal(value, out) == nil
+}
+
+// put stores an entry. A ttl of zero or less never expires.
+func (c *Cache) put(key, kind string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var expiresAt sql.NullInt64
+	if ttl > 0 {
+		expiresAt = sql.NullInt64{Int64: now.Add(ttl).Unix(), Valid: true}
+	}
+	query := `INSERT OR REPLACE INTO cache_entries (key, kind, value, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`
+	_, err = c.db.Exec(query, key, kind, value, now.Unix(), expiresAt)
+	return err
+}
+
+// cacheKey hashes the JSON encoding of parts.
+func cacheKey(parts interface{}) string {
+	b, _ := json.Marshal(parts)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+type cacheReadKey struct{}
+
+// WithCache returns a context whose LLM calls may be answered with cached
+// responses. It is meant for previews and prompt tuning: without it every call
+// reaches the provider, so runs that publish or schedule posts never repeat an
+// earlier response. Fresh responses are written to the cache either way.
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheReadKey{}, true)
+}
+
+func cacheReadable(ctx context.Context) bool {
+	read, _ := ctx.Value(cacheReadKey{}).(bool)
+	return read
+}
+
+// ModelName returns the model a built-in client is configured with, or "" for other clients.
+func ModelName(client interface{}) string {
+	switch c := client.(type) {
+	case *GeminiClient:
+		return c.Model
+	case *OllamaClient:
+		return c.Model
+	case *OpenAIClient:
+		return c.Model
+	case *AnthropicClient:
+		return c.Model
+	case *GeminiEmbeddingClient:
+		return c.Model
+	case *OllamaEmbeddingClient:
+		return c.Model
+	case *CachedLLM:
+		return c.Model
+	case *CachedEmbedding:
+		return c.Model
+	case *ScriptedLLM:
+		return c.Model
+	case *HashEmbedding:
+		return fmt.Sprintf("hash-%d", c.Dimensions)
+	case *LocalEmbedding:
+		return c.Model()
+	case *FallbackEmbedding:
+		return ModelName(c.Primary)
+	}
+	return ""
+}
+
+// CachedLLM serves repeated requests from a Cache to callers that opt in with
+// WithCache. Failed calls are not cached.
+type CachedLLM struct {
+	LLM      LLMTool
+	Cache    *Cache
+	Provider string
+	Model    string
+}
+
+// NewCachedLLM wraps llm with cache. With a nil cache llm is returned as is.
+func NewCachedLLM(cache *Cache, provider, model string, llm LLMTool) LLMTool {
+	if cache == nil {
+		return llm
+	}
+	return &CachedLLM{LLM: llm, Cache: cache, Provider: provider, Model: model}
+}
+
+func (c *CachedLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return c.call(ctx, req, nil)
+}
+
+// GenerateStream implements StreamingLLM. Cached responses arrive as a single delta.
+func (c *CachedLLM) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	return c.call(ctx, req, onDelta)
+}
+
+func (c *CachedLLM) call(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	key := cacheKey(struct {
+		Kind     string
+		Provider string
+		Model    string
+		Request  LLMRequest
+	}{cacheKindLLM, c.Provider, c.Model, req})
+
+	if cacheReadable(ctx) {
+		var cached LLMResponse
+		if c.Cache.get(key, &cached) {
+			c.Cache.llmHits.Add(1)
+			cached.Cached = true
+			if onDelta != nil {
+				onDelta(cached.Text)
+			}
+			return &cached, nil
+		}
+	}
+	c.Cache.llmMisses.Add(1)
+
+	res, err := Stream(ctx, c.LLM, req, onDelta)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Cache.put(key, cacheKindLLM, res, c.Cache.TTL); err != nil {
+		countLLM(c.Provider, "cache_write_errors")
+	}
+	return res, nil
+}
+
+// CachedEmbedding computes each distinct text once per provider and model.
+type CachedEmbedding struct {
+	Embedding EmbeddingTool
+	Cache     *Cache
+	Provider  string
+	Model     string
+}
+
+// NewCachedEmbedding wraps embedding with cache. With a nil cache embedding is returned as is.
+func NewCachedEmbedding(cache *Cache, provider, model string, embedding EmbeddingTool) EmbeddingTool {
+	if cache == nil {
+		return embedding
+	}
+	return &CachedEmbedding{Embedding: embedding, Cache: cache, Provider: provider, Model: model}
+}
+
+func (c *CachedEmbedding) Embed(text string) ([]float32, error) {
+	vecs, _, err := c.EmbedMetered("", []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch implements BatchEmbedding.
+func (c *CachedEmbedding) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs, _, err := c.EmbedMetered("", texts)
+	return vecs, err
+}
+
+// EmbedMetered implements MeteredEmbedding. Only the texts missing from the
+// cache are sent, in one batch; the call is reported as cached when none were.
+func (c *CachedEmbedding) EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error) {
+	dimensions := embeddingDimensions(c.Embedding)
+	keys := make([]string, len(texts))
+	vecs := make([][]float32, len(texts))
+	var missing []int
+	for i, text := range texts {
+		keys[i] = cacheKey(struct {
+			Kind       string
+			Provider   string
+			Model      string
+			Task       string `json:",omitempty"`
+			Dimensions int    `json:",omitempty"`
+			Text       string
+		}{cacheKindEmbedding, c.Provider, c.Model, task, dimensions, text})
+
+		if c.Cache.get(keys[i], &vecs[i]) {
+			c.Cache.embeddingHits.Add(1)
+			continue
+		}
+		c.Cache.embeddingMisses.Add(1)
+		missing = append(missing, i)
+	}
+	if len(missing) == 0 {
+		return vecs, EmbeddingUsage{Model: c.Model, Cached: true}, nil
+	}
+
+	batch := make([]string, len(missing))
+	for j, i := range missing {
+		batch[j] = texts[i]
+	}
+	fresh, usage, err := EmbedMetered(c.Embedding, task, batch...)
+	if err != nil {
+		return nil, usage, err
+	}
+	for j, i := range missing {
+		vecs[i] = fresh[j]
+		if err := c.Cache.put(keys[i], cacheKindEmbedding, fresh[j], 0); err != nil {
+			countLLM(c.Provider, "cache_write_errors")
+		}
+	}
+	return vecs, usage, nil
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestCache(t *testing.T) *Cache {
+	t.Helper()
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestCachedLLMKeys(t *testing.T) {
+	cache := openTestCache(t)
+	stub := &stubLLM{outcome: func(call int) (*LLMResponse, error) {
+		return &LLMResponse{Text: "answer", Model: "m"}, nil
+	}}
+	llm := NewCachedLLM(cache, "openai", "m", stub)
+	ctx := WithCache(context.Background())
+
+	first, err := llm.Generate(ctx, Prompt("sys", "hi"))
+	if err != nil || first.Cached {
+		t.Fatalf("first call: %+v, %v", first, err)
+	}
+	again, err := llm.Generate(ctx, Prompt("sys", "hi"))
+	if err != nil || !again.Cached || again.Text != "answer" || stub.calls != 1 {
+		t.Fatalf("repeat call: %+v, %v after %d provider calls, want a cache hit", again, err, stub.calls)
+	}
+
+	temp := 0.5
+	different := Prompt("sys", "hi")
+	different.Temperature = &temp
+	for _, req := range []LLMRequest{Prompt("sys", "hello"), Prompt("other", "hi"), different} {
+		if res, _ := llm.Generate(ctx, req); res.Cached {
+			t.Errorf("request %+v served from another request's entry", req)
+		}
+	}
+	for _, other := range []LLMTool{NewCachedLLM(cache, "gemini", "m", stub), NewCachedLLM(cache, "openai", "m2", stub)} {
+		if res, _ := other.Generate(ctx, Prompt("sys", "hi")); res.Cached {
+			t.Errorf("%+v served another provider's or model's entry", other)
+		}
+	}
+
+	if res, _ := llm.Generate(context.Background(), Prompt("sys", "hi")); res.Cached {
+		t.Error("call without WithCache served a cached response")
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.LLMHits != 1 || stats.LLMEntries != 6 {
+		t.Errorf("stats = %+v, want 1 hit and 6 entries", stats)
+	}
+}
+
+func TestCachedLLMSkipsFailures(t *testing.T) {
+	cache := openTestCache(t)
+	stub := &stubLLM{outcome: failing(1, errUnavailable)}
+	llm := NewCachedLLM(cache, "openai", "m", stub)
+	ctx := WithCache(context.Background())
+
+	if _, err := llm.Generate(ctx, Prompt("", "hi")); !errors.Is(err, errUnavailable) {
+		t.Fatalf("err = %v", err)
+	}
+	res, err := llm.Generate(ctx, Prompt("", "hi"))
+	if err != nil || res.Cached || stub.calls != 2 {
+		t.Errorf("after a failure: %+v, %v after %d calls, want a fresh call", res, err, stub.calls)
+	}
+}
+
+func TestCachedLLMTTL(t *testing.T) {
+	cache := openTestCache(t)
+	stub := &stubLLM{outcome: failing(0, nil)}
+	llm := NewCachedLLM(cache, "openai", "m", stub)
+	ctx := WithCache(context.Background())
+
+	cache.TTL = time.Nanosecond // Expires within the second it was written
+	llm.Generate(ctx, Prompt("", "short"))
+	if res, _ := llm.Generate(ctx, Prompt("", "short")); res.Cached {
+		t.Error("expired response served")
+	}
+
+	cache.TTL = 0
+	llm.Generate(ctx, Prompt("", "forever"))
+	if res, _ := llm.Generate(ctx, Prompt("", "forever")); !res.Cached {
+		t.Error("response without TTL not served")
+	}
+}
+
+func TestOpenCacheDropsExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	cache, err := OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.db.Exec(`INSERT INTO cache_entries (key, kind, value, created_at, expires_at) VALUES ('old', ?, '""', 0, ?)`,
+		cacheKindLLM, time.Now().Add(-time.Hour).Unix())
+	cache.put("live", cacheKindLLM, "v", time.Hour)
+	cache.Close()
+
+	cache, err = OpenCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+	var rows int
+	cache.db.QueryRow(`SELECT COUNT(*) FROM cache_entries`).Scan(&rows)
+	if rows != 1 {
+		t.Errorf("%d rows after reopening, want only the live entry", rows)
+	}
+}
+
+func TestNewCachedWithoutCache(t *testing.T) {
+	stub := &stubLLM{}
+	if llm := NewCachedLLM(nil, "p", "m", stub); llm != LLMTool(stub) {
+		t.Error("NewCachedLLM without a cache wrapped the client")
+	}
+	hash := NewHashEmbedding(8)
+	if e := NewCachedEmbedding(nil, "p", "m", hash); e != EmbeddingTool(hash) {
+		t.Error("NewCachedEmbedding without a cache wrapped the client")
+	}
+}
+
+// countingEmbedding records the batches sent to a HashEmbedding.
+type countingEmbedding struct {
+	HashEmbedding
+	batches [][]string
+	tasks   []string
+}
+
+func (c *countingEmbedding) EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error) {
+	c.batches = append(c.batches, texts)
+	c.tasks = append(c.tasks, task)
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vecs[i], _ = c.Embed(text)
+	}
+	return vecs, EmbeddingUsage{Model: "hash", Tokens: len(texts)}, nil
+}
+
+func TestCachedEmbedding(t *testing.T) {
+	cache := openTestCache(t)
+	inner := &countingEmbedding{HashEmbedding: HashEmbedding{Dimensions: 16}}
+	embedding := NewCachedEmbedding(cache, "gemini", "m", inner)
+
+	first, usage, err := EmbedMetered(embedding, TaskRetrievalDocument, "alpha", "beta")
+	if err != nil || usage.Cached || len(first) != 2 {
+		t.Fatalf("first batch: %d vectors, %+v, %v", len(first), usage, err)
+	}
+	vecs, usage, err := EmbedMetered(embedding, TaskRetrievalDocument, "beta", "gamma", "alpha")
+	if err != nil || usage.Cached {
+		t.Fatalf("second batch: %+v, %v", usage, err)
+	}
+	if len(inner.batches) != 2 || len(inner.batches[1]) != 1 || inner.batches[1][0] != "gamma" {
+		t.Errorf("batches sent = %q, want only the missing text the second time", inner.batches)
+	}
+	if !equalVectors(vecs[0], first[1]) || !equalVectors(vecs[2], first[0]) {
+		t.Error("cached vectors returned out of order")
+	}
+
+	_, usage, _ = EmbedMetered(embedding, TaskRetrievalDocument, "alpha")
+	if !usage.Cached || len(inner.batches) != 2 {
+		t.Errorf("fully cached batch: %+v after %d batches", usage, len(inner.batches))
+	}
+
+	// The task type changes the vector, so it is part of the key.
+	EmbedMetered(embedding, TaskRetrievalQuery, "alpha")
+	if len(inner.batches) != 3 || inner.tasks[2] != TaskRetrievalQuery {
+		t.Errorf("query embedding served from the document entry")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 )
@@ -14,11 +13,94 @@ type EmbeddingTool interface {
 	Embed(text string) ([]float32, error)
 }
 
+// BatchEmbedding is implemented by embedding clients that embed several texts in one call.
+type BatchEmbedding interface {
+	EmbedBatch(texts []string) ([][]float32, error)
+}
+
+// Embedding tasks. Gemini tunes vectors to the task they serve; other
+// providers ignore it. An empty task uses the client's default.
+const (
+	TaskRetrievalDocument = "RETRIEVAL_DOCUMENT" // Texts kept in vector memory
+	TaskRetrievalQuery    = "RETRIEVAL_QUERY"    // Texts used to search vector memory
+)
+
+// EmbeddingUsage describes what one embedding call consumed.
+type EmbeddingUsage struct {
+	Model  string
+	Tokens int
+	Cached bool // Served from the cache at no cost
+}
+
+// MeteredEmbedding is implemented by embedding clients that report their
+// usage and accept a task.
+type MeteredEmbedding interface {
+	EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error)
+}
+
+// EmbedMetered embeds texts for task and reports the usage. Clients that do
+// not report usage themselves are charged an estimate of the texts' tokens
+// and embed without a task.
+func EmbedMetered(e EmbeddingTool, task string, texts ...string) ([][]float32, EmbeddingUsage, error) {
+	if m, ok := e.(MeteredEmbedding); ok {
+		return m.EmbedMetered(task, texts)
+	}
+	usage := EmbeddingUsage{Model: ModelName(e)}
+	for _, text := range texts {
+		usage.Tokens += EstimateTokens(text)
+	}
+	vecs, err := EmbedBatch(e, texts)
+	return vecs, usage, err
+}
+
+// EmbedBatch embeds texts in one call when the client supports it and one at
+// a time otherwise.
+func EmbedBatch(e EmbeddingTool, texts []string) ([][]float32, error) {
+	if b, ok := e.(BatchEmbedding); ok {
+		return b.EmbedBatch(texts)
+	}
+	vecs := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		vec, err := e.Embed(text)
+		if err != nil {
+			return nil, err
+		}
+		vecs = append(vecs, vec)
+	}
+	return vecs, nil
+}
+
+// EstimateTokens approximates the token count of text at four bytes per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// embeddingDimensions returns the vector size a built-in client is configured
+// with, or 0 for the model's default.
+func embeddingDimensions(client EmbeddingTool) int {
+	switch c := client.(type) {
+	case *GeminiEmbeddingClient:
+		return c.Dimensions
+	case *OllamaEmbeddingClient:
+		return c.Dimensions
+	case *HashEmbedding:
+		return c.Dimensions
+	case *LocalEmbedding:
+		return c.Dimensions
+	}
+	return 0
+}
+
+// geminiMaxBatch is the most texts batchEmbedContents accepts per call.
+const geminiMaxBatch = 100
+
 // GeminiEmbeddingClient implements EmbeddingTool using Google Gemini API.
 type GeminiEmbeddingClient struct {
-	APIKey string
-	Model  string
-	client *http.Client
+	APIKey     string
+	Model      string
+	TaskType   string // Task used when the caller names none, e.g. TaskRetrievalDocument; empty leaves it to the API
+	Dimensions int    // Output dimensionality; 0 keeps the model's full size
+	client     *http.Client
 }
 
 func NewGeminiEmbeddingClient(apiKey, model string) *GeminiEmbeddingClient {
@@ -34,29 +116,71 @@ func NewGeminiEmbeddingClient(apiKey, model string) *GeminiEmbeddingClient {
 	}
 }
 
-type geminiEmbeddingRequest struct {
-	Model   string `json:"model"`
-	Content struct {
-		Parts []struct {
-			Text string `json:"text"`
-		} `json:"parts"`
-	} `json:"content"`
+type geminiEmbedContentRequest struct {
+	Model                string        `json:"model"`
+	Content              geminiContent `json:"content"`
+	TaskType             string        `json:"taskType,omitempty"`
+	OutputDimensionality int           `json:"outputDimensionality,omitempty"`
+}
+
+type geminiBatchEmbedRequest struct {
+	Requests []geminiEmbedContentRequest `json:"requests"`
 }
 
-type geminiEmbeddingResponse struct {
-	Embedding struct {
+type geminiBatchEmbedResponse struct {
+	Embeddings []struct {
 		Values []float32 `json:"values"`
-	} `json:"embedding"`
+	} `json:"embeddings"`
 }
 
 func (g *GeminiEmbeddingClient) Embed(text string) ([]float32, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:embedContent?key=%s", g.Model, g.APIKey)
+	vecs, err := g.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
 
-	reqBody := geminiEmbeddingRequest{}
-	reqBody.Model = "models/" + g.Model
-	reqBody.Content.Parts = append(reqBody.Content.Parts, struct {
-		Text string `json:"text"`
-	}{Text: text})
+// EmbedBatch implements BatchEmbedding using batchEmbedContents.
+func (g *GeminiEmbeddingClient) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs, _, err := g.EmbedMetered("", texts)
+	return vecs, err
+}
+
+// EmbedMetered implements MeteredEmbedding. The API does not report token
+// counts, so usage is estimated.
+func (g *GeminiEmbeddingClient) EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error) {
+	if task == "" {
+		task = g.TaskType
+	}
+	usage := EmbeddingUsage{Model: g.Model}
+	vecs := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += geminiMaxBatch {
+		end := min(start+geminiMaxBatch, len(texts))
+		batch, err := g.embedBatch(task, texts[start:end])
+		if err != nil {
+			return nil, usage, err
+		}
+		vecs = append(vecs, batch...)
+	}
+	for _, text := range texts {
+		usage.Tokens += EstimateTokens(text)
+	}
+	return vecs, usage, nil
+}
+
+func (g *GeminiEmbeddingClient) embedBatch(task string, texts []string) ([][]float32, error) {
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:batchEmbedContents?key=%s", g.Model, g.APIKey)
+
+	reqBody := geminiBatchEmbedRequest{}
+	for _, text := range texts {
+		reqBody.Requests = append(reqBody.Requests, geminiEmbedContentRequest{
+			Model:                "models/" + g.Model,
+			Content:              geminiContent{Parts: []geminiPart{{Text: text}}},
+			TaskType:             task,
+			OutputDimensionality: g.Dimensions,
+		})
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -70,14 +194,99 @@ func (g *GeminiEmbeddingClient) Embed(text string) ([]float32, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("gemini embedding error %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError("gemini embedding", resp)
 	}
 
-	var embedResp geminiEmbeddingResponse
+	var embedResp geminiBatchEmbedResponse
 	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
 		return nil, err
 	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("gemini returned %d embeddings for %d texts", len(embedResp.Embeddings), len(texts))
+	}
+
+	vecs := make([][]float32, len(texts))
+	for i, e := range embedResp.Embeddings {
+		vecs[i] = e.Values
+	}
+	return vecs, nil
+}
+
+// OllamaEmbeddingClient implements EmbeddingTool with a local Ollama server's /api/embed.
+type OllamaEmbeddingClient struct {
+	Model      string
+	BaseURL    string
+	Dimensions int // Output dimensionality for models that support it; 0 keeps the model's size
+	client     *http.Client
+}
+
+func NewOllamaEmbeddingClient(model string) *OllamaEmbeddingClient {
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+	return &OllamaEmbeddingClient{
+		Model:   model,
+		BaseURL: "http://localhost:11434/api/embed",
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+type ollamaEmbedRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type ollamaEmbedResponse struct {
+	Model           string      `json:"model"`
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+func (o *OllamaEmbeddingClient) Embed(text string) ([]float32, error) {
+	vecs, err := o.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch implements BatchEmbedding.
+func (o *OllamaEmbeddingClient) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs, _, err := o.EmbedMetered("", texts)
+	return vecs, err
+}
 
-	return embedResp.Embedding.Values, nil
+// EmbedMetered implements MeteredEmbedding. Ollama has no task types.
+func (o *OllamaEmbeddingClient) EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error) {
+	usage := EmbeddingUsage{Model: o.Model}
+	if len(texts) == 0 {
+		return nil, usage, nil
+	}
+	jsonBody, err := json.Marshal(ollamaEmbedRequest{Model: o.Model, Input: texts, Dimensions: o.Dimensions})
+	if err != nil {
+		return nil, usage, err
+	}
+
+	resp, err := o.client.Post(o.BaseURL, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, usage, fmt.Errorf("failed to connect to ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, usage, newAPIError("ollama embedding", resp)
+	}
+
+	var embedResp ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, usage, err
+	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, usage, fmt.Errorf("ollama returned %d embeddings for %d texts", len(embedResp.Embeddings), len(texts))
+	}
+	usage.Tokens = embedResp.PromptEvalCount
+	return embedResp.Embeddings, usage, nil
 }
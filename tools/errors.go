@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StatusOverloaded is returned by providers such as Anthropic when they are temporarily overloaded.
+const StatusOverloaded = 529
+
+// APIError is a non-success HTTP response from a model provider.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	RetryAfter time.Duration // Zero when the provider did not say
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api error %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if it is sent again later:
+// rate limits, overload and transient server errors.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, StatusOverloaded,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// newAPIError reads a failed response into an APIError, honouring Retry-After.
+func newAPIError(provider string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		Body:       string(body),
+	}
+}
+
+// parseRetryAfter accepts both forms of the Retry-After header: seconds or an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"content-creator-agent/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fake providers run the agent without network access, for demos, local
+// development and integration tests. They are reproducible rather than pure:
+// scripted responses are chosen by rule match and call order, so the same
+// sequence of requests always gets the same responses.
+
+// ScriptRule answers requests whose prompt matches Match. Responses are used
+// in turn, one per matching call, and may refer to submatches of Match as $1,
+// ${name} and so on.
+type ScriptRule struct {
+	Name      string   `json:"name"`
+	Match     string   `json:"match"` // Regular expression tried against the system prompt and all messages
+	Responses []string `json:"responses"`
+
+	re *regexp.Regexp
+}
+
+// Script is the set of rules a ScriptedLLM answers from. The first matching
+// rule wins; Default answers everything else. Without a Default unmatched
+// requests fail.
+type Script struct {
+	Model   string       `json:"model"`
+	Rules   []ScriptRule `json:"rules"`
+	Default string       `json:"default"`
+}
+
+// ScriptedLLM implements LLMTool with canned responses.
+type ScriptedLLM struct {
+	Model string
+	Rules []ScriptRule
+	// Default answers requests no rule matches.
+	Default string
+
+	mu       sync.Mutex
+	uses     map[int]int
+	requests []LLMRequest
+}
+
+// NewScriptedLLM compiles the script's rules.
+func NewScriptedLLM(script Script) (*ScriptedLLM, error) {
+	s := &ScriptedLLM{Model: script.Model, Default: script.Default, uses: make(map[int]int)}
+	if s.Model == "" {
+		s.Model = "scripted"
+	}
+	for i, rule := range script.Rules {
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, rule.Name, err)
+		}
+		if len(rule.Responses) == 0 {
+			return nil, fmt.Errorf("rule %d (%s) has no responses", i, rule.Name)
+		}
+		rule.re = re
+		s.Rules = append(s.Rules, rule)
+	}
+	return s, nil
+}
+
+// LoadScriptedLLM reads a Script from a JSON file.
+func LoadScriptedLLM(path string) (*ScriptedLLM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var script Script
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse script %s: %w", path, err)
+	}
+	return NewScriptedLLM(script)
+}
+
+func (s *ScriptedLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(req.System)
+	for _, m := range req.Messages {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(m.Content)
+	}
+	text := prompt.String()
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	response, matched := s.Default, false
+	for i, rule := range s.Rules {
+		match := rule.re.FindStringSubmatchIndex(text)
+		if match == nil {
+			continue
+		}
+		template := rule.Responses[s.uses[i]%len(rule.Responses)]
+		s.uses[i]++
+		response = string(rule.re.ExpandString(nil, template, text, match))
+		matched = true
+		break
+	}
+	s.mu.Unlock()
+
+	if !matched && response == "" {
+		return nil, fmt.Errorf("no scripted response matches the request")
+	}
+	promptTokens, completionTokens := EstimateTokens(text), EstimateTokens(response)
+	return &LLMResponse{
+		Text:         response,
+		Model:        s.Model,
+		FinishReason: "stop",
+		Usage: TokenUsage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+	}, nil
+}
+
+// GenerateStream implements StreamingLLM, delivering the response a word at a time.
+func (s *ScriptedLLM) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	res, err := s.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.SplitAfter(res.Text, " ") {
+		if word != "" {
+			onDelta(word)
+		}
+	}
+	return res, nil
+}
+
+// Requests returns the requests received so far, in order.
+func (s *ScriptedLLM) Requests() []LLMRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LLMRequest(nil), s.requests...)
+}
+
+// DefaultHashDimensions is the vector size of NewHashEmbedding.
+const DefaultHashDimensions = 256
+
+// HashEmbedding implements EmbeddingTool by hashing the words of a text into
+// a fixed number of buckets. Texts sharing words get similar vectors, which
+// is enough for duplicate detection and recall to behave sensibly offline.
+type HashEmbedding struct {
+	Dimensions int
+}
+
+func NewHashEmbedding(dimensions int) *HashEmbedding {
+	if dimensions <= 0 {
+		dimensions = DefaultHashDimensions
+	}
+	return &HashEmbedding{Dimensions: dimensions}
+}
+
+func (h *HashEmbedding) Embed(text string) ([]float32, error) {
+	vec := make([]float32, h.Dimensions)
+	for _, word := range embeddingWords(text) {
+		bucket, sign := hashFeature(word, h.Dimensions)
+		vec[bucket] += sign
+	}
+	return normalize(vec), nil
+}
+
+// MockAnalyticsFetcher implements AnalyticsFetcher with metrics derived from
+// a hash of the post content, so that posts differ in engagement but a post
+// always reports the same numbers.
+type MockAnalyticsFetcher struct{}
+
+func (MockAnalyticsFetcher) Fetch(post *models.Post) (models.Analytics, error) {
+	if post.SocialID == "" {
+		return models.Analytics{}, fmt.Errorf("post has no social ID")
+	}
+	f := fnv.New32a()
+	f.Write([]byte(post.Content))
+	sum := int(f.Sum32() % 1000)
+	return models.Analytics{
+		Views:    500 + sum*5,
+		Likes:    10 + sum%90,
+		Shares:   1 + sum%15,
+		Comments: sum % 25,
+	}, nil
+}
+
+// FixtureSearch implements SearchTool from a fixed list of trends. A fixture
+// with a Query only answers searches containing it, ignoring case; the others
+// answer every search. Results are stamped with the search time.
+type FixtureSearch struct {
+	Trends []models.Trend
+}
+
+// LoadFixtureSearch reads a JSON array of trends.
+func LoadFixtureSearch(path string) (*FixtureSearch, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var trends []models.Trend
+	if err := json.Unmarshal(data, &trends); err != nil {
+		return nil, fmt.Errorf("failed to parse search fixtures %s: %w", path, err)
+	}
+	return &FixtureSearch{Trends: trends}, nil
+}
+
+func (f *FixtureSearch) Search(query string) ([]models.Trend, error) {
+	now := time.Now()
+	var results []models.Trend
+	for _, t := range f.Trends {
+		if t.Query != "" && !strings.Contains(strings.ToLower(query), strings.ToLower(t.Query)) {
+			continue
+		}
+		t.Query = query
+		t.Timestamp = now
+		results = append(results, t)
+	}
+	return results, nil
+}
@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"content-creator-agent/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestScriptedLLMRules(t *testing.T) {
+	llm, err := NewScriptedLLM(Script{
+		Rules: []ScriptRule{
+			{Name: "plan", Match: `Topic: (?P<topic>[^\n]+)`, Responses: []string{"first ${topic}", "second $1"}},
+			{Name: "system", Match: `^You are a critic`, Responses: []string{"critique"}},
+			{Name: "shadowed", Match: `Topic`, Responses: []string{"never"}},
+		},
+		Default: "fallback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if llm.Model != "scripted" {
+		t.Errorf("model = %q, want the default name", llm.Model)
+	}
+
+	ask := func(system, user string) string {
+		t.Helper()
+		res, err := llm.Generate(context.Background(), Prompt(system, user))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Text
+	}
+	tests := []struct {
+		system, user, want string
+	}{
+		{"", "Topic: Go generics\nmore", "first Go generics"},
+		{"", "Topic: Rust", "second Rust"},
+		{"", "Topic: Zig", "first Zig"}, // Responses cycle
+		{"You are a critic.", "Score this", "critique"},
+		{"", "Something else", "fallback"},
+	}
+	for _, tt := range tests {
+		if got := ask(tt.system, tt.user); got != tt.want {
+			t.Errorf("%q / %q = %q, want %q", tt.system, tt.user, got, tt.want)
+		}
+	}
+	if got := len(llm.Requests()); got != len(tests) {
+		t.Errorf("recorded %d requests, want %d", got, len(tests))
+	}
+}
+
+func TestScriptedLLMWithoutDefault(t *testing.T) {
+	llm, err := NewScriptedLLM(Script{Model: "m", Rules: []ScriptRule{{Match: "hi", Responses: []string{"hello"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.Generate(context.Background(), Prompt("", "bye")); err == nil {
+		t.Error("unmatched request without a default succeeded")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := llm.Generate(ctx, Prompt("", "hi")); err == nil {
+		t.Error("cancelled request succeeded")
+	}
+}
+
+func TestScriptedLLMInvalidRules(t *testing.T) {
+	if _, err := NewScriptedLLM(Script{Rules: []ScriptRule{{Match: "(", Responses: []string{"x"}}}}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+	if _, err := NewScriptedLLM(Script{Rules: []ScriptRule{{Match: "x"}}}); err == nil {
+		t.Error("rule without responses accepted")
+	}
+}
+
+func TestScriptedLLMStream(t *testing.T) {
+	llm, _ := NewScriptedLLM(Script{Default: "one two three"})
+	var deltas []string
+	res, err := llm.GenerateStream(context.Background(), Prompt("", "x"), func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(deltas, "|") != "one |two |three" || res.Text != "one two three" {
+		t.Errorf("deltas = %q, text = %q", deltas, res.Text)
+	}
+	if res.Usage.TotalTokens != res.Usage.PromptTokens+res.Usage.CompletionTokens || res.Usage.CompletionTokens == 0 {
+		t.Errorf("usage = %+v", res.Usage)
+	}
+}
+
+func TestScriptedLLMRepositoryScript(t *testing.T) {
+	if _, err := LoadScriptedLLM("../config/fake/llm.json"); err != nil {
+		t.Fatal(err)
+	}
+	search, err := LoadFixtureSearch("../config/fake/trends.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trends, _ := search.Search("anything"); len(trends) == 0 {
+		t.Error("fixtures answer no search")
+	}
+}
+
+func TestFixtureSearchQueries(t *testing.T) {
+	search := &FixtureSearch{Trends: []models.Trend{
+		{Title: "everywhere"},
+		{Title: "go only", Query: "Golang"},
+	}}
+	if got, _ := search.Search("golang news"); len(got) != 2 || got[1].Query != "golang news" || got[1].Timestamp.IsZero() {
+		t.Errorf("matching search = %+v", got)
+	}
+	if got, _ := search.Search("rust"); len(got) != 1 || got[0].Title != "everywhere" {
+		t.Errorf("other search = %+v", got)
+	}
+}
+
+func TestMockAnalyticsFetcher(t *testing.T) {
+	post := &models.Post{SocialID: "mock-1", Content: "hello"}
+	a, err := MockAnalyticsFetcher{}.Fetch(post)
+	b, _ := MockAnalyticsFetcher{}.Fetch(post)
+	if err != nil || a != b || a.Views == 0 {
+		t.Errorf("got %+v and %+v, %v; want the same non-zero metrics", a, b, err)
+	}
+	if _, err := (MockAnalyticsFetcher{}).Fetch(&models.Post{}); err == nil {
+		t.Error("post without a social ID fetched")
+	}
+
+	multi := &MultiAnalyticsFetcher{Fetchers: map[string]AnalyticsFetcher{"mock": MockAnalyticsFetcher{}}}
+	post.Platform = "twitter"
+	if got, err := multi.Fetch(post); err != nil || got != a {
+		t.Errorf("mock fallback = %+v, %v", got, err)
+	}
+}
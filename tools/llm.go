@@ -2,16 +2,69 @@ package tools
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
 // LLMTool defines the interface for text generation.
 type LLMTool interface {
-	Generate(systemPrompt, userPrompt string) (string, error)
+	Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error)
+}
+
+// Message roles in an LLM conversation.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// LLMMessage is one turn of a conversation.
+type LLMMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// LLMRequest is a single generation call. Zero-valued options use the provider's defaults.
+type LLMRequest struct {
+	System      string
+	Messages    []LLMMessage // Alternating user and assistant turns, ending with a user turn
+	Temperature *float64
+	MaxTokens   int
+	JSON        bool            // Constrain the response to a single JSON object
+	Schema      json.RawMessage // Optional JSON Schema for the response; implies JSON
+}
+
+// Prompt builds a single-turn request.
+func Prompt(systemPrompt, userPrompt string) LLMRequest {
+	return LLMRequest{
+		System:   systemPrompt,
+		Messages: []LLMMessage{{Role: RoleUser, Content: userPrompt}},
+	}
+}
+
+// WantsJSON reports whether the response must be a JSON object.
+func (r LLMRequest) WantsJSON() bool {
+	return r.JSON || len(r.Schema) > 0
+}
+
+// TokenUsage counts the tokens consumed by one LLM call.
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// LLMResponse is a completion together with the metadata the provider reported.
+type LLMResponse struct {
+	Text         string
+	Model        string
+	FinishReason string // Why generation stopped, e.g. "stop", "max_tokens"; empty if not reported
+	Usage        TokenUsage
+	Cached       bool `json:"-"` // Served from the response cache
 }
 
 // GeminiClient implements LLMTool using Google's Gemini REST API.
@@ -39,14 +92,21 @@ type geminiPart struct {
 }
 
 type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
 	Parts []geminiPart `json:"parts"`
 }
 
+type geminiGenerationConfig struct {
+	Temperature        *float64        `json:"temperature,omitempty"`
+	MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
 type geminiRequest struct {
-	Contents          []geminiContent `json:"contents"`
-	SystemInstruction struct {
-		Parts []geminiPart `json:"parts"`
-	} `json:"system_instruction,omitempty"`
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"system_instruction,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
 }
 
 type geminiResponse struct {
@@ -56,61 +116,156 @@ type geminiResponse struct {
 				Text string `json:"text"`
 			} `json:"parts"`
 		} `json:"content"`
+		FinishReason string `json:"finishReason"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
 }
 
-func (g *GeminiClient) Generate(systemPrompt, userPrompt string) (string, error) {
-	if g.APIKey == "" {
-		return "", fmt.Errorf("gemini api key is required")
+func (g *GeminiClient) Generate(ctx context.Context, r LLMRequest) (*LLMResponse, error) {
+	resp, err := g.post(ctx, "generateContent", r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var gemResp geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gemResp); err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", g.Model, g.APIKey)
+	if len(gemResp.Candidates) == 0 || len(gemResp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("empty response from gemini")
+	}
 
-	req := geminiRequest{}
-	req.Contents = append(req.Contents, geminiContent{
-		Parts: []geminiPart{
-			{Text: userPrompt},
-		},
+	res := &LLMResponse{Text: gemResp.Candidates[0].Content.Parts[0].Text}
+	g.finish(res, &gemResp)
+	return res, nil
+}
+
+// GenerateStream implements StreamingLLM using streamGenerateContent over SSE.
+func (g *GeminiClient) GenerateStream(ctx context.Context, r LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	resp, err := g.post(ctx, "streamGenerateContent", r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &LLMResponse{}
+	var text strings.Builder
+	err = readSSE(resp.Body, func(data []byte) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if len(chunk.Candidates) > 0 {
+			for _, part := range chunk.Candidates[0].Content.Parts {
+				if part.Text != "" {
+					text.WriteString(part.Text)
+					onDelta(part.Text)
+				}
+			}
+		}
+		g.finish(res, &chunk)
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("empty response from gemini")
+	}
+	res.Text = text.String()
+	return res, nil
+}
 
-	if systemPrompt != "" {
-		req.SystemInstruction.Parts = append(req.SystemInstruction.Parts, geminiPart{
-			Text: systemPrompt,
+// post sends a request to one of the model's methods and checks the status.
+func (g *GeminiClient) post(ctx context.Context, method string, r LLMRequest) (*http.Response, error) {
+	if g.APIKey == "" {
+		return nil, fmt.Errorf("gemini api key is required")
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:%s?key=%s", g.Model, method, g.APIKey)
+	if method == "streamGenerateContent" {
+		url += "&alt=sse"
+	}
+
+	req := geminiRequest{}
+	for _, m := range r.Messages {
+		role := "user"
+		if m.Role == RoleAssistant {
+			role = "model"
+		}
+		req.Contents = append(req.Contents, geminiContent{
+			Role:  role,
+			Parts: []geminiPart{{Text: m.Content}},
 		})
 	}
 
+	if r.System != "" {
+		req.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: r.System}}}
+	}
+
+	if r.Temperature != nil || r.MaxTokens > 0 || r.WantsJSON() {
+		req.GenerationConfig = &geminiGenerationConfig{
+			Temperature:        r.Temperature,
+			MaxOutputTokens:    r.MaxTokens,
+			ResponseJSONSchema: r.Schema,
+		}
+		if r.WantsJSON() {
+			req.GenerationConfig.ResponseMimeType = "application/json"
+		}
+	}
+
 	jsonBody, err := json.Marshal(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	resp, err := g.client.Post(url, "application/json", bytes.NewBuffer(jsonBody))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
+	httpReq.Header.Set("Content-Type", "application/json")
 
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("gemini api error %d: %s", resp.StatusCode, string(body))
+		defer resp.Body.Close()
+		return nil, newAPIError("gemini", resp)
 	}
+	return resp, nil
+}
 
-	var gemResp geminiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&gemResp); err != nil {
-		return "", err
+// finish copies the model, finish reason and usage reported in a response
+// or stream chunk. Streams report them on the final chunk.
+func (g *GeminiClient) finish(res *LLMResponse, gemResp *geminiResponse) {
+	res.Model = gemResp.ModelVersion
+	if res.Model == "" {
+		res.Model = g.Model
 	}
-
-	if len(gemResp.Candidates) == 0 || len(gemResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from gemini")
+	if len(gemResp.Candidates) > 0 && gemResp.Candidates[0].FinishReason != "" {
+		res.FinishReason = gemResp.Candidates[0].FinishReason
+	}
+	if usage := gemResp.UsageMetadata; usage.TotalTokenCount > 0 {
+		res.Usage = TokenUsage{
+			PromptTokens:     usage.PromptTokenCount,
+			CompletionTokens: usage.CandidatesTokenCount,
+			TotalTokens:      usage.TotalTokenCount,
+		}
 	}
-
-	return gemResp.Candidates[0].Content.Parts[0].Text, nil
 }
 
 // OllamaClient implements LLMTool connecting to a local Ollama instance.
 type OllamaClient struct {
 	Model   string
-	BaseURL string
+	BaseURL string // Chat endpoint, e.g. "http://localhost:11434/api/chat"
 	client  *http.Client
 }
 
@@ -120,53 +275,136 @@ func NewOllamaClient(model string) *OllamaClient {
 	}
 	return &OllamaClient{
 		Model:   model,
-		BaseURL: "http://localhost:11434/api/generate",
+		BaseURL: "http://localhost:11434/api/chat",
 		client: &http.Client{
 			Timeout: 120 * time.Second, // Long timeout for generation
 		},
 	}
 }
 
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
 type ollamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	System string `json:"system"`
-	Stream bool   `json:"stream"`
+	Model    string          `json:"model"`
+	Messages []LLMMessage    `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   json.RawMessage `json:"format,omitempty"` // "json" or a JSON Schema
+	Options  *ollamaOptions  `json:"options,omitempty"`
 }
 
 type ollamaResponse struct {
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+func (o *OllamaClient) Generate(ctx context.Context, r LLMRequest) (*LLMResponse, error) {
+	resp, err := o.post(ctx, r, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ollamaResp ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	res := &LLMResponse{Text: ollamaResp.Message.Content}
+	o.finish(res, &ollamaResp)
+	return res, nil
+}
+
+// GenerateStream implements StreamingLLM. Ollama streams one JSON object per line.
+func (o *OllamaClient) GenerateStream(ctx context.Context, r LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	resp, err := o.post(ctx, r, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &LLMResponse{}
+	var text strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			o.finish(res, &chunk)
+			break
+		}
+	}
+	res.Text = text.String()
+	return res, nil
 }
 
-func (o *OllamaClient) Generate(systemPrompt, userPrompt string) (string, error) {
+func (o *OllamaClient) post(ctx context.Context, r LLMRequest, stream bool) (*http.Response, error) {
 	reqBody := ollamaRequest{
 		Model:  o.Model,
-		Prompt: userPrompt,
-		System: systemPrompt,
-		Stream: false,
+		Stream: stream,
+	}
+	if r.System != "" {
+		reqBody.Messages = append(reqBody.Messages, LLMMessage{Role: "system", Content: r.System})
+	}
+	reqBody.Messages = append(reqBody.Messages, r.Messages...)
+	if len(r.Schema) > 0 {
+		reqBody.Format = r.Schema
+	} else if r.JSON {
+		reqBody.Format = json.RawMessage(`"json"`)
+	}
+	if r.Temperature != nil || r.MaxTokens > 0 {
+		reqBody.Options = &ollamaOptions{Temperature: r.Temperature, NumPredict: r.MaxTokens}
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := o.client.Post(o.BaseURL, "application/json", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.BaseURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return "", fmt.Errorf("ollama request failed (is ollama running?): %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	req.Header.Set("Content-Type", "application/json")
 
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama request failed (is ollama running?): %w", err)
+	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama error %d: %s", resp.StatusCode, string(body))
+		defer resp.Body.Close()
+		return nil, newAPIError("ollama", resp)
 	}
+	return resp, nil
+}
 
-	var ollamaResp ollamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+// finish copies the model, finish reason and token counts of a final response.
+func (o *OllamaClient) finish(res *LLMResponse, ollamaResp *ollamaResponse) {
+	res.Model = ollamaResp.Model
+	if res.Model == "" {
+		res.Model = o.Model
+	}
+	res.FinishReason = ollamaResp.DoneReason
+	res.Usage = TokenUsage{
+		PromptTokens:     ollamaResp.PromptEvalCount,
+		CompletionTokens: ollamaResp.EvalCount,
+		TotalTokens:      ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
 	}
-
-	return ollamaResp.Response, nil
 }
@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"content-creator-agent/tools/logger"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultLocalDimensions is the vector size of NewLocalEmbedding.
+const DefaultLocalDimensions = 512
+
+// maxVocabularyTerms bounds the terms whose document frequency is tracked.
+// Terms first seen after that are weighted like unseen ones.
+const maxVocabularyTerms = 50000
+
+// LocalEmbedding implements EmbeddingTool without network access. Texts are
+// vectorized as TF-IDF weighted counts of words and word pairs, hashed into a
+// fixed number of dimensions so that vectors stay comparable as the vocabulary
+// grows. Texts embedded as documents update the document frequencies, which
+// are persisted to Path.
+type LocalEmbedding struct {
+	Path       string // Vocabulary file; empty keeps the statistics in memory
+	Dimensions int
+
+	mu    sync.Mutex
+	vocab localVocabulary
+}
+
+// localVocabulary is the persisted IDF state of a LocalEmbedding.
+type localVocabulary struct {
+	Documents int            `json:"documents"`
+	Terms     map[string]int `json:"terms"` // Number of documents containing each term
+}
+
+// localEmbeddings shares one LocalEmbedding per vocabulary file.
+var localEmbeddings sync.Map
+
+// NewLocalEmbedding returns the embedder whose statistics are kept at path,
+// loading them on first use. Embedders are shared per path so that concurrent
+// runs of a brand update the same statistics.
+func NewLocalEmbedding(path string) *LocalEmbedding {
+	if path != "" {
+		if l, ok := localEmbeddings.Load(path); ok {
+			return l.(*LocalEmbedding)
+		}
+	}
+	l := &LocalEmbedding{Path: path, Dimensions: DefaultLocalDimensions}
+	l.vocab.Terms = make(map[string]int)
+	if path == "" {
+		return l
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &l.vocab); err != nil {
+			logger.GlobalBuffer.Warn("Ignoring unreadable embedding vocabulary %s: %v", path, err)
+			l.vocab = localVocabulary{}
+		}
+		if l.vocab.Terms == nil {
+			l.vocab.Terms = make(map[string]int)
+		}
+	}
+	actual, _ := localEmbeddings.LoadOrStore(path, l)
+	return actual.(*LocalEmbedding)
+}
+
+// Model names the vector space, which depends on the dimensions.
+func (l *LocalEmbedding) Model() string {
+	return fmt.Sprintf("local-tfidf-%d", l.Dimensions)
+}
+
+func (l *LocalEmbedding) Embed(text string) ([]float32, error) {
+	vecs, _, err := l.EmbedMetered("", []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch implements BatchEmbedding.
+func (l *LocalEmbedding) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs, _, err := l.EmbedMetered("", texts)
+	return vecs, err
+}
+
+// EmbedMetered implements MeteredEmbedding. With TaskRetrievalDocument the
+// texts are first added to the document frequencies and saved; any other task
+// leaves the statistics untouched.
+func (l *LocalEmbedding) EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	terms := make([][]string, len(texts))
+	usage := EmbeddingUsage{Model: l.Model()}
+	for i, text := range texts {
+		terms[i] = localTerms(text)
+		usage.Tokens += EstimateTokens(text)
+	}
+	if task == TaskRetrievalDocument && len(texts) > 0 {
+		l.learn(terms)
+		if err := l.save(); err != nil {
+			logger.GlobalBuffer.Warn("Failed to save embedding vocabulary %s: %v", l.Path, err)
+		}
+	}
+
+	vecs := make([][]float32, len(texts))
+	for i := range terms {
+		vecs[i] = l.vectorize(terms[i])
+	}
+	return vecs, usage, nil
+}
+
+// learn counts each distinct term of every document once.
+func (l *LocalEmbedding) learn(docs [][]string) {
+	for _, terms := range docs {
+		l.vocab.Documents++
+		seen := make(map[string]bool)
+		for _, term := range terms {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			if _, ok := l.vocab.Terms[term]; ok || len(l.vocab.Terms) < maxVocabularyTerms {
+				l.vocab.Terms[term]++
+			}
+		}
+	}
+}
+
+// save writes the vocabulary through a temporary file so a crash never leaves it half written.
+func (l *LocalEmbedding) save() error {
+	if l.Path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(l.vocab)
+	if err != nil {
+		return err
+	}
+	tmp := l.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.Path)
+}
+
+// vectorize weights each term by (1 + ln tf) * idf, with the smoothed
+// idf = ln((1 + N) / (1 + df)) + 1, and normalizes the result.
+func (l *LocalEmbedding) vectorize(terms []string) []float32 {
+	counts := make(map[string]int)
+	for _, term := range terms {
+		counts[term]++
+	}
+	vec := make([]float32, l.Dimensions)
+	for term, tf := range counts {
+		idf := math.Log(float64(1+l.vocab.Documents)/float64(1+l.vocab.Terms[term])) + 1
+		bucket, sign := hashFeature(term, l.Dimensions)
+		vec[bucket] += sign * float32((1+math.Log(float64(tf)))*idf)
+	}
+	return normalize(vec)
+}
+
+// localTerms returns the words of a text followed by its adjacent word pairs.
+func localTerms(text string) []string {
+	words := embeddingWords(text)
+	terms := append([]string(nil), words...)
+	for i := 1; i < len(words); i++ {
+		terms = append(terms, words[i-1]+" "+words[i])
+	}
+	return terms
+}
+
+// embeddingWords splits text into lower-cased words.
+func embeddingWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// hashFeature maps a feature to a bucket and a sign, which keeps colliding
+// features from always adding up.
+func hashFeature(feature string, dimensions int) (int, float32) {
+	f := fnv.New32a()
+	f.Write([]byte(feature))
+	sum := f.Sum32()
+	sign := float32(1)
+	if sum&(1<<31) != 0 {
+		sign = -1
+	}
+	return int(sum % uint32(dimensions)), sign
+}
+
+// normalize scales vec to unit length in place. Zero vectors are left as is.
+func normalize(vec []float32) []float32 {
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// FallbackEmbedding embeds with Primary and, when that fails, with Fallback.
+// Callers tell the two vector spaces apart by the model in the usage report.
+type FallbackEmbedding struct {
+	Primary  EmbeddingTool
+	Fallback EmbeddingTool
+}
+
+// NewFallbackEmbedding wraps primary with fallback. Without a primary the
+// fallback is returned as is.
+func NewFallbackEmbedding(primary, fallback EmbeddingTool) EmbeddingTool {
+	if primary == nil {
+		return fallback
+	}
+	return &FallbackEmbedding{Primary: primary, Fallback: fallback}
+}
+
+func (f *FallbackEmbedding) Embed(text string) ([]float32, error) {
+	vecs, _, err := f.EmbedMetered("", []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch implements BatchEmbedding.
+func (f *FallbackEmbedding) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs, _, err := f.EmbedMetered("", texts)
+	return vecs, err
+}
+
+// EmbedMetered implements MeteredEmbedding.
+func (f *FallbackEmbedding) EmbedMetered(task string, texts []string) ([][]float32, EmbeddingUsage, error) {
+	vecs, usage, err := EmbedMetered(f.Primary, task, texts...)
+	if err == nil {
+		return vecs, usage, nil
+	}
+	name := ModelName(f.Primary)
+	countLLM(name, "embedding_fallbacks")
+	logger.GlobalBuffer.Warn("Embedding with %s failed (%v), falling back to %s", name, err, ModelName(f.Fallback))
+	vecs, usage, fallbackErr := EmbedMetered(f.Fallback, task, texts...)
+	if fallbackErr != nil {
+		return nil, usage, fmt.Errorf("%s: %v; fallback: %w", name, err, fallbackErr)
+	}
+	return vecs, usage, nil
+}
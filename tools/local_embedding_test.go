@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+func TestLocalEmbeddingVectors(t *testing.T) {
+	l := NewLocalEmbedding("")
+	l.Dimensions = 64
+
+	vec, err := l.Embed("Go generics in practice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vec) != 64 || math.Abs(cosine(vec, vec)-1) > 1e-5 {
+		t.Errorf("got %d dimensions with norm² %v, want a unit vector of 64", len(vec), cosine(vec, vec))
+	}
+	same, _ := l.Embed("go GENERICS, in practice!")
+	if !equalVectors(vec, same) {
+		t.Error("case and punctuation changed the vector")
+	}
+	if empty, _ := l.Embed("!!!"); cosine(empty, empty) != 0 {
+		t.Error("text without words has a non-zero vector")
+	}
+	if l.Model() != "local-tfidf-64" {
+		t.Errorf("model = %q", l.Model())
+	}
+}
+
+func TestLocalEmbeddingLearnsOnlyDocuments(t *testing.T) {
+	l := NewLocalEmbedding("")
+	before, _ := l.Embed("rust compiler release")
+
+	for _, task := range []string{"", TaskRetrievalQuery} {
+		if _, _, err := l.EmbedMetered(task, []string{"rust compiler release"}); err != nil {
+			t.Fatal(err)
+		}
+		if l.vocab.Documents != 0 {
+			t.Fatalf("task %q learned %d documents", task, l.vocab.Documents)
+		}
+	}
+
+	_, usage, err := l.EmbedMetered(TaskRetrievalDocument, []string{"rust compiler release", "rust rust rust"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.vocab.Documents != 2 || l.vocab.Terms["rust"] != 2 || l.vocab.Terms["compiler release"] != 1 {
+		t.Errorf("vocabulary = %+v, want each distinct term counted once per document", l.vocab)
+	}
+	if usage.Model != l.Model() || usage.Tokens == 0 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if after, _ := l.Embed("rust compiler release"); equalVectors(before, after) {
+		t.Error("learning left the weights unchanged")
+	}
+}
+
+func TestLocalEmbeddingIDF(t *testing.T) {
+	l := NewLocalEmbedding("")
+	var docs []string
+	for i := 0; i < 20; i++ {
+		docs = append(docs, "news about startups")
+	}
+	l.EmbedMetered(TaskRetrievalDocument, append(docs, "kubernetes operators"))
+
+	// Sharing only the common word must count for less than sharing the rare one.
+	query, _ := l.Embed("startups kubernetes")
+	common, _ := l.Embed("startups")
+	rare, _ := l.Embed("kubernetes")
+	if cosine(query, rare) <= cosine(query, common) {
+		t.Errorf("rare term similarity %v not above common term similarity %v", cosine(query, rare), cosine(query, common))
+	}
+}
+
+func TestLocalEmbeddingPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brand", "vocabulary.json")
+	l := NewLocalEmbedding(path)
+	if NewLocalEmbedding(path) != l {
+		t.Error("embedders for the same file are not shared")
+	}
+
+	l.EmbedMetered(TaskRetrievalQuery, []string{"draft that was never published"})
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("query embedding wrote the vocabulary: %v", err)
+	}
+	l.EmbedMetered(TaskRetrievalDocument, []string{"published post"})
+	want, _ := l.Embed("published post")
+
+	localEmbeddings.Delete(path) // Simulate a restart
+	reloaded := NewLocalEmbedding(path)
+	if reloaded == l || reloaded.vocab.Documents != 1 {
+		t.Fatalf("reloaded %d documents, want 1", reloaded.vocab.Documents)
+	}
+	if got, _ := reloaded.Embed("published post"); !equalVectors(got, want) {
+		t.Error("reloaded vocabulary weights terms differently")
+	}
+
+	other := filepath.Join(t.TempDir(), "vocabulary.json")
+	os.WriteFile(other, []byte(`{not json`), 0644)
+	if l := NewLocalEmbedding(other); l.vocab.Documents != 0 || l.vocab.Terms == nil {
+		t.Errorf("unreadable vocabulary loaded as %+v", l.vocab)
+	}
+}
+
+// errEmbeddingDown is returned by failingEmbedding.
+var errEmbeddingDown = errors.New("embedding service down")
+
+// failingEmbedding fails every call.
+type failingEmbedding struct{}
+
+func (failingEmbedding) Embed(string) ([]float32, error) { return nil, errEmbeddingDown }
+
+func TestFallbackEmbedding(t *testing.T) {
+	local := NewLocalEmbedding("")
+	if NewFallbackEmbedding(nil, local) != EmbeddingTool(local) {
+		t.Error("fallback without a primary was wrapped")
+	}
+
+	primary := NewHashEmbedding(16)
+	_, usage, err := EmbedMetered(NewFallbackEmbedding(primary, local), TaskRetrievalDocument, "a", "b")
+	if err != nil || usage.Model != "hash-16" || local.vocab.Documents != 0 {
+		t.Errorf("healthy primary: %+v, %v; want the primary's model and the fallback unused", usage, err)
+	}
+
+	vecs, usage, err := EmbedMetered(NewFallbackEmbedding(failingEmbedding{}, local), TaskRetrievalDocument, "a", "b")
+	if err != nil || len(vecs) != 2 || usage.Model != local.Model() {
+		t.Errorf("failing primary: %d vectors, %+v, %v; want the local model", len(vecs), usage, err)
+	}
+	if local.vocab.Documents != 2 {
+		t.Errorf("fallback learned %d documents, want the task passed through", local.vocab.Documents)
+	}
+
+	both := NewFallbackEmbedding(failingEmbedding{}, failingEmbedding{})
+	if _, _, err := EmbedMetered(both, "", "a"); !errors.Is(err, errEmbeddingDown) {
+		t.Errorf("err = %v, want the fallback's error", err)
+	}
+}
+
+func equalVectors(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
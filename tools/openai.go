@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenAIClient implements LLMTool for any server that speaks the OpenAI
+// /v1/chat/completions protocol: OpenAI itself, Azure OpenAI, vLLM, the
+// llama.cpp server or LM Studio.
+type OpenAIClient struct {
+	BaseURL      string // e.g. "https://api.openai.com/v1" or "http://localhost:8000/v1"; query parameters are kept
+	APIKey       string // Optional for local servers
+	APIKeyHeader string // Header carrying the raw key, e.g. "api-key" for Azure; empty sends a Bearer token
+	Model        string
+	Client       *http.Client
+}
+
+func NewOpenAIClient(baseURL, apiKey, model string) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	return &OpenAIClient{
+		BaseURL: baseURL,
+		APIKey:  apiKey,
+		Model:   model,
+		Client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+type openAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema *struct {
+		Name   string          `json:"name"`
+		Schema json.RawMessage `json:"schema"`
+	} `json:"json_schema,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []LLMMessage          `json:"messages"`
+	Temperature    *float64              `json:"temperature,omitempty"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+	Stream         bool                  `json:"stream,omitempty"`
+	StreamOptions  *openAIStreamOptions  `json:"stream_options,omitempty"`
+}
+
+// openAIResponse is a completion or, when streaming, one chunk of it. Chunks
+// carry their text in Delta instead of Message.
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Generate implements LLMTool. JSON requests use the json_object response
+// format, or json_schema when a schema is given.
+func (o *OpenAIClient) Generate(ctx context.Context, r LLMRequest) (*LLMResponse, error) {
+	resp, err := o.post(ctx, r, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var oaResp openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&oaResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(oaResp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from openai")
+	}
+
+	res := &LLMResponse{Text: oaResp.Choices[0].Message.Content}
+	o.finish(res, &oaResp)
+	return res, nil
+}
+
+// GenerateStream implements StreamingLLM. Usage is requested with
+// stream_options and arrives on the last chunk; servers that ignore the
+// option leave it zero.
+func (o *OpenAIClient) GenerateStream(ctx context.Context, r LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	resp, err := o.post(ctx, r, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &LLMResponse{}
+	var text strings.Builder
+	err = readSSE(resp.Body, func(data []byte) error {
+		var chunk openAIResponse
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			text.WriteString(chunk.Choices[0].Delta.Content)
+			onDelta(chunk.Choices[0].Delta.Content)
+		}
+		o.finish(res, &chunk)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("empty response from openai")
+	}
+	res.Text = text.String()
+	return res, nil
+}
+
+// post sends a chat completion request and checks the status.
+func (o *OpenAIClient) post(ctx context.Context, r LLMRequest, stream bool) (*http.Response, error) {
+	endpoint, err := url.Parse(o.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openai base url: %w", err)
+	}
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/chat/completions"
+
+	reqBody := openAIRequest{
+		Model:       o.Model,
+		Temperature: r.Temperature,
+		MaxTokens:   r.MaxTokens,
+	}
+	if r.System != "" {
+		reqBody.Messages = append(reqBody.Messages, LLMMessage{Role: "system", Content: r.System})
+	}
+	reqBody.Messages = append(reqBody.Messages, r.Messages...)
+	if len(r.Schema) > 0 {
+		reqBody.ResponseFormat = &openAIResponseFormat{Type: "json_schema"}
+		reqBody.ResponseFormat.JSONSchema = &struct {
+			Name   string          `json:"name"`
+			Schema json.RawMessage `json:"schema"`
+		}{Name: "response", Schema: r.Schema}
+	} else if r.JSON {
+		reqBody.ResponseFormat = &openAIResponseFormat{Type: "json_object"}
+	}
+	if stream {
+		reqBody.Stream = true
+		reqBody.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		if o.APIKeyHeader != "" {
+			req.Header.Set(o.APIKeyHeader, o.APIKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+o.APIKey)
+		}
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError("openai", resp)
+	}
+	return resp, nil
+}
+
+// finish copies the model, finish reason and usage reported in a response
+// or stream chunk.
+func (o *OpenAIClient) finish(res *LLMResponse, oaResp *openAIResponse) {
+	if oaResp.Model != "" {
+		res.Model = oaResp.Model
+	} else if res.Model == "" {
+		res.Model = o.Model
+	}
+	if len(oaResp.Choices) > 0 && oaResp.Choices[0].FinishReason != "" {
+		res.FinishReason = oaResp.Choices[0].FinishReason
+	}
+	if usage := oaResp.Usage; usage != nil {
+		res.Usage = TokenUsage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// openAIStandIn serves /v1/chat/completions with handle and records the last request.
+func openAIStandIn(t *testing.T, handle func(w http.ResponseWriter, req openAIRequest)) (*OpenAIClient, *http.Request) {
+	t.Helper()
+	var last http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = *r
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handle(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return NewOpenAIClient(srv.URL+"/v1", "sk-test", "gpt-test"), &last
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	temp := 0.2
+	var got openAIRequest
+	client, last := openAIStandIn(t, func(w http.ResponseWriter, req openAIRequest) {
+		got = req
+		fmt.Fprint(w, `{
+			"model": "gpt-test-0125",
+			"choices": [{"message": {"role": "assistant", "content": "Hello there"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 3, "total_tokens": 15}
+		}`)
+	})
+
+	req := Prompt("Be brief.", "Say hello")
+	req.Temperature = &temp
+	req.MaxTokens = 50
+	res, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if auth := last.Header.Get("Authorization"); auth != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got.Model != "gpt-test" || got.MaxTokens != 50 || got.Temperature == nil || *got.Temperature != 0.2 {
+		t.Errorf("request options not sent: %+v", got)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[0].Content != "Be brief." ||
+		got.Messages[1].Role != RoleUser || got.Messages[1].Content != "Say hello" {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+	if got.ResponseFormat != nil || got.Stream {
+		t.Errorf("plain request sent response_format %+v, stream %v", got.ResponseFormat, got.Stream)
+	}
+
+	if res.Text != "Hello there" || res.Model != "gpt-test-0125" || res.FinishReason != "stop" {
+		t.Errorf("response = %+v", res)
+	}
+	if res.Usage != (TokenUsage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}) {
+		t.Errorf("usage = %+v", res.Usage)
+	}
+}
+
+func TestOpenAIGenerateJSONMode(t *testing.T) {
+	var formats []*openAIResponseFormat
+	client, _ := openAIStandIn(t, func(w http.ResponseWriter, req openAIRequest) {
+		formats = append(formats, req.ResponseFormat)
+		fmt.Fprint(w, `{"choices": [{"message": {"content": "{\"ok\": true}"}}]}`)
+	})
+
+	req := Prompt("", "json please")
+	req.JSON = true
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	req.Schema = json.RawMessage(`{"type":"object"}`)
+	res, err := client.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if formats[0] == nil || formats[0].Type != "json_object" || formats[0].JSONSchema != nil {
+		t.Errorf("JSON request format = %+v", formats[0])
+	}
+	if formats[1] == nil || formats[1].Type != "json_schema" || formats[1].JSONSchema == nil ||
+		formats[1].JSONSchema.Name != "response" || string(formats[1].JSONSchema.Schema) != `{"type":"object"}` {
+		t.Errorf("schema request format = %+v", formats[1])
+	}
+	if res.Model != "gpt-test" {
+		t.Errorf("model without one reported = %q, want the configured model", res.Model)
+	}
+	if res.Usage != (TokenUsage{}) {
+		t.Errorf("usage without one reported = %+v", res.Usage)
+	}
+}
+
+func TestOpenAIGenerateStream(t *testing.T) {
+	var got openAIRequest
+	client, _ := openAIStandIn(t, func(w http.ResponseWriter, req openAIRequest) {
+		got = req
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"model":"gpt-test-0125","choices":[{"delta":{"role":"assistant"}}]}`,
+			`{"model":"gpt-test-0125","choices":[{"delta":{"content":"Hel"}}]}`,
+			`{"model":"gpt-test-0125","choices":[{"delta":{"content":"lo"}}]}`,
+			`{"model":"gpt-test-0125","choices":[{"delta":{},"finish_reason":"length"}]}`,
+			`{"model":"gpt-test-0125","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	})
+
+	var deltas []string
+	res, err := client.GenerateStream(context.Background(), Prompt("", "hi"), func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Stream || got.StreamOptions == nil || !got.StreamOptions.IncludeUsage {
+		t.Errorf("stream request = %+v", got)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if res.Text != "Hello" || res.Model != "gpt-test-0125" || res.FinishReason != "length" {
+		t.Errorf("response = %+v", res)
+	}
+	if res.Usage != (TokenUsage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}) {
+		t.Errorf("usage = %+v", res.Usage)
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	client, _ := openAIStandIn(t, func(w http.ResponseWriter, req openAIRequest) {
+		if req.Messages[0].Content == "empty" {
+			fmt.Fprint(w, `{"choices": []}`)
+			return
+		}
+		w.Header().Set("Retry-After", "2")
+		http.Error(w, `{"error":"slow down"}`, http.StatusTooManyRequests)
+	})
+
+	_, err := client.Generate(context.Background(), Prompt("", "limited"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || !apiErr.Retryable() || apiErr.RetryAfter.Seconds() != 2 {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+
+	if _, err := client.Generate(context.Background(), Prompt("", "empty")); err == nil {
+		t.Error("empty choices returned no error")
+	}
+}
+
+func TestOpenAIKeepsQueryAndKeyHeader(t *testing.T) {
+	client, last := openAIStandIn(t, func(w http.ResponseWriter, req openAIRequest) {
+		fmt.Fprint(w, `{"choices": [{"message": {"content": "ok"}}]}`)
+	})
+	client.BaseURL += "?api-version=2024-06-01"
+	client.APIKeyHeader = "api-key"
+
+	if _, err := client.Generate(context.Background(), Prompt("", "hi")); err != nil {
+		t.Fatal(err)
+	}
+	if q := last.URL.Query().Get("api-version"); q != "2024-06-01" {
+		t.Errorf("api-version = %q", q)
+	}
+	if last.Header.Get("api-key") != "sk-test" || last.Header.Get("Authorization") != "" {
+		t.Errorf("headers = %v", last.Header)
+	}
+}
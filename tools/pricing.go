@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// ModelPrice is what a model costs in US dollars per million tokens.
+type ModelPrice struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// PriceTable maps model names to prices. A key also matches any model name
+// it is a prefix of, so "gpt-4o-mini" prices "gpt-4o-mini-2024-07-18"; the
+// longest matching key wins.
+type PriceTable map[string]ModelPrice
+
+// DefaultPrices are indicative list prices. Override them with a JSON file
+// in the same shape, see LoadPriceTable. Models not listed, such as local
+// Ollama models, cost nothing, so budgets do not limit them; list them with
+// a price, even zero, to say that is intended.
+var DefaultPrices = PriceTable{
+	"gemini-2.5-flash":     {InputPerMTok: 0.30, OutputPerMTok: 2.50},
+	"gemini-2.5-pro":       {InputPerMTok: 1.25, OutputPerMTok: 10.00},
+	"gemini-3-flash":       {InputPerMTok: 0.50, OutputPerMTok: 3.00},
+	"gemini-3-pro":         {InputPerMTok: 2.00, OutputPerMTok: 12.00},
+	"gemini-embedding-001": {InputPerMTok: 0.15},
+	"gpt-4o":               {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+	"gpt-4o-mini":          {InputPerMTok: 0.15, OutputPerMTok: 0.60},
+	"claude-haiku-4-5":     {InputPerMTok: 1.00, OutputPerMTok: 5.00},
+	"claude-sonnet-4-5":    {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+	"claude-opus-4-1":      {InputPerMTok: 15.00, OutputPerMTok: 75.00},
+	"local-tfidf":          {}, // LocalEmbedding
+	"hash-":                {}, // HashEmbedding
+	"scripted":             {}, // ScriptedLLM
+}
+
+// LoadPriceTable reads a price table from a JSON file and lays it over the
+// defaults. A missing file yields the defaults.
+func LoadPriceTable(path string) (PriceTable, error) {
+	table := make(PriceTable, len(DefaultPrices))
+	for model, price := range DefaultPrices {
+		table[model] = price
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return table, nil
+		}
+		return nil, err
+	}
+	var overrides PriceTable
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, err
+	}
+	for model, price := range overrides {
+		table[model] = price
+	}
+	return table, nil
+}
+
+// Lookup returns the price of a model and whether the table lists it.
+// Provider-reported model names such as "models/gemini-2.5-flash" are accepted.
+func (t PriceTable) Lookup(model string) (ModelPrice, bool) {
+	model = strings.TrimPrefix(model, "models/")
+	best, found := "", false
+	for key := range t {
+		if strings.HasPrefix(model, key) && (!found || len(key) > len(best)) {
+			best, found = key, true
+		}
+	}
+	return t[best], found
+}
+
+// Cost prices a call. Unlisted models cost nothing.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, _ := t.Lookup(model)
+	return (float64(promptTokens)*price.InputPerMTok + float64(completionTokens)*price.OutputPerMTok) / 1e6
+}
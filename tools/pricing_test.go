@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPriceTableLookup(t *testing.T) {
+	table := PriceTable{
+		"gpt-4o":      {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+		"gpt-4o-mini": {InputPerMTok: 0.15, OutputPerMTok: 0.60},
+		"gemini-2.5":  {InputPerMTok: 1},
+		"free":        {},
+	}
+	tests := []struct {
+		model string
+		want  ModelPrice
+		found bool
+	}{
+		{"gpt-4o", table["gpt-4o"], true},
+		{"gpt-4o-2024-08-06", table["gpt-4o"], true},
+		{"gpt-4o-mini", table["gpt-4o-mini"], true},
+		{"gpt-4o-mini-2024-07-18", table["gpt-4o-mini"], true},
+		{"models/gemini-2.5-flash", table["gemini-2.5"], true},
+		{"free-model", ModelPrice{}, true},
+		{"llama3.1:8b", ModelPrice{}, false},
+		{"", ModelPrice{}, false},
+	}
+	for _, tt := range tests {
+		got, found := table.Lookup(tt.model)
+		if got != tt.want || found != tt.found {
+			t.Errorf("Lookup(%q) = %+v, %v; want %+v, %v", tt.model, got, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestPriceTableCost(t *testing.T) {
+	table := PriceTable{"gpt-4o-mini": {InputPerMTok: 0.15, OutputPerMTok: 0.60}}
+	if got := table.Cost("gpt-4o-mini-2024-07-18", 1_000_000, 500_000); math.Abs(got-0.45) > 1e-9 {
+		t.Errorf("cost = %v, want 0.45", got)
+	}
+	if got := table.Cost("unlisted", 1_000_000, 1_000_000); got != 0 {
+		t.Errorf("unlisted model cost %v", got)
+	}
+	if got := PriceTable(nil).Cost("gpt-4o", 10, 10); got != 0 {
+		t.Errorf("nil table cost %v", got)
+	}
+}
+
+func TestDefaultPricesListOfflineModels(t *testing.T) {
+	for _, model := range []string{NewLocalEmbedding("").Model(), ModelName(NewHashEmbedding(0)), "scripted"} {
+		if price, ok := DefaultPrices.Lookup(model); !ok || price != (ModelPrice{}) {
+			t.Errorf("%s: %+v, %v; want listed as free", model, price, ok)
+		}
+	}
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	dir := t.TempDir()
+	table, err := LoadPriceTable(filepath.Join(dir, "missing.json"))
+	if err != nil || len(table) != len(DefaultPrices) {
+		t.Fatalf("missing file: %d prices, %v; want the defaults", len(table), err)
+	}
+
+	path := filepath.Join(dir, "prices.json")
+	os.WriteFile(path, []byte(`{"gpt-4o": {"input_per_mtok": 1}, "llama3": {}}`), 0644)
+	table, err = LoadPriceTable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table["gpt-4o"].InputPerMTok != 1 || table["gpt-4o"].OutputPerMTok != 0 {
+		t.Errorf("override not applied: %+v", table["gpt-4o"])
+	}
+	if _, ok := table.Lookup("llama3.1:8b"); !ok {
+		t.Error("added model not listed")
+	}
+	if table["gpt-4o-mini"] != DefaultPrices["gpt-4o-mini"] {
+		t.Error("defaults not kept")
+	}
+	if DefaultPrices["gpt-4o"].InputPerMTok == 1 {
+		t.Error("LoadPriceTable changed DefaultPrices")
+	}
+
+	os.WriteFile(path, []byte(`{not json`), 0644)
+	if _, err := LoadPriceTable(path); err == nil {
+		t.Error("invalid file loaded")
+	}
+}
@@ -0,0 +1,351 @@
+package tools
+
+import (
+	"content-creator-agent/tools/logger"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// llmMetrics counts the decisions of the resilience wrappers, keyed
+// "<provider>.<event>". It is published on the expvar endpoint as "llm".
+var llmMetrics = expvar.NewMap("llm")
+
+func countLLM(name, event string) {
+	llmMetrics.Add(name+"."+event, 1)
+}
+
+// ErrCircuitOpen is returned without calling the provider while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Defaults used by NewResilientLLM.
+const (
+	DefaultLLMAttempts       = 3
+	DefaultLLMRetryBaseDelay = time.Second
+	DefaultLLMRetryMaxDelay  = 30 * time.Second
+	DefaultLLMCallTimeout    = 90 * time.Second
+	DefaultBreakerThreshold  = 5
+	DefaultBreakerCooldown   = time.Minute
+)
+
+// NewResilientLLM wraps a provider with a circuit breaker, retries and a
+// per-call timeout, using the defaults above. A sequence of retries counts as
+// a single failure for the breaker.
+func NewResilientLLM(name string, llm LLMTool) LLMTool {
+	return NewCircuitBreakerLLM(name, NewRetryLLM(name, NewTimeoutLLM(name, llm, DefaultLLMCallTimeout)))
+}
+
+// isTransient reports whether a failed call may succeed if sent again: a
+// retryable API status, a network timeout, or a per-call timeout that fired
+// while the caller's context was still live.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// TimeoutLLM bounds each call to the wrapped LLM.
+type TimeoutLLM struct {
+	LLM     LLMTool
+	Name    string
+	Timeout time.Duration
+}
+
+func NewTimeoutLLM(name string, llm LLMTool, timeout time.Duration) *TimeoutLLM {
+	return &TimeoutLLM{LLM: llm, Name: name, Timeout: timeout}
+}
+
+func (t *TimeoutLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return t.call(ctx, req, nil)
+}
+
+// GenerateStream implements StreamingLLM; the timeout covers the whole stream.
+func (t *TimeoutLLM) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	return t.call(ctx, req, onDelta)
+}
+
+func (t *TimeoutLLM) call(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	if t.Timeout <= 0 {
+		return Stream(ctx, t.LLM, req, onDelta)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	res, err := Stream(callCtx, t.LLM, req, onDelta)
+	if err != nil && ctx.Err() == nil && callCtx.Err() == context.DeadlineExceeded {
+		countLLM(t.Name, "timeouts")
+		logger.GlobalBuffer.Warn("LLM %s timed out after %v", t.Name, t.Timeout)
+		return nil, fmt.Errorf("%s call timed out after %v: %w", t.Name, t.Timeout, context.DeadlineExceeded)
+	}
+	return res, err
+}
+
+// RetryLLM retries transient failures with exponential backoff and jitter.
+// A Retry-After sent by the provider takes precedence over the backoff; one
+// longer than MaxDelay fails the call right away so that a fallback provider
+// can take over instead of the run stalling.
+type RetryLLM struct {
+	LLM         LLMTool
+	Name        string
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration // Longest wait between attempts
+}
+
+func NewRetryLLM(name string, llm LLMTool) *RetryLLM {
+	return &RetryLLM{
+		LLM:         llm,
+		Name:        name,
+		MaxAttempts: DefaultLLMAttempts,
+		BaseDelay:   DefaultLLMRetryBaseDelay,
+		MaxDelay:    DefaultLLMRetryMaxDelay,
+	}
+}
+
+func (r *RetryLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return r.call(ctx, req, nil)
+}
+
+// GenerateStream implements StreamingLLM. A stream that fails after text was
+// delivered is not retried, since the text cannot be taken back.
+func (r *RetryLLM) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	return r.call(ctx, req, onDelta)
+}
+
+func (r *RetryLLM) call(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	streamed := false
+	var relay func(string)
+	if onDelta != nil {
+		relay = func(delta string) {
+			streamed = true
+			onDelta(delta)
+		}
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var res *LLMResponse
+		res, err = Stream(ctx, r.LLM, req, relay)
+		if err == nil {
+			return res, nil
+		}
+		if attempt >= r.MaxAttempts || streamed || !isTransient(ctx, err) {
+			return nil, err
+		}
+
+		delay := r.backoff(attempt, err)
+		if r.MaxDelay > 0 && delay > r.MaxDelay {
+			logger.GlobalBuffer.Warn("LLM %s failed (%v) and asks to wait %v, more than %v; giving up", r.Name, err, delay, r.MaxDelay)
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			logger.GlobalBuffer.Warn("LLM %s failed (%v); retry in %v would pass the deadline, giving up", r.Name, err, delay)
+			return nil, err
+		}
+		countLLM(r.Name, "retries")
+		logger.GlobalBuffer.Warn("LLM %s attempt %d/%d failed (%v), retrying in %v", r.Name, attempt, r.MaxAttempts, err, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before the next attempt: the provider's
+// Retry-After if it sent one, otherwise an exponential delay with jitter in
+// [d/2, d).
+func (r *RetryLLM) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	d := r.BaseDelay << (attempt - 1)
+	if d <= 0 || d > r.MaxDelay {
+		d = r.MaxDelay
+	}
+	if d < 2 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// Circuit breaker states.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// CircuitBreakerLLM stops calling a provider after Threshold consecutive
+// failures. Once Cooldown has passed a single probe call is let through: a
+// success closes the circuit, a failure opens it again.
+type CircuitBreakerLLM struct {
+	LLM       LLMTool
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+func NewCircuitBreakerLLM(name string, llm LLMTool) *CircuitBreakerLLM {
+	return &CircuitBreakerLLM{
+		LLM:       llm,
+		Name:      name,
+		Threshold: DefaultBreakerThreshold,
+		Cooldown:  DefaultBreakerCooldown,
+		state:     breakerClosed,
+	}
+}
+
+func (c *CircuitBreakerLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return c.call(ctx, req, nil)
+}
+
+// GenerateStream implements StreamingLLM.
+func (c *CircuitBreakerLLM) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	return c.call(ctx, req, onDelta)
+}
+
+func (c *CircuitBreakerLLM) call(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	if err := c.allow(); err != nil {
+		countLLM(c.Name, "breaker_rejected")
+		return nil, err
+	}
+	res, err := Stream(ctx, c.LLM, req, onDelta)
+	c.record(ctx, err)
+	return res, err
+}
+
+// allow decides whether a call may go through, moving an open circuit to
+// half-open once the cooldown has passed.
+func (c *CircuitBreakerLLM) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case breakerOpen:
+		if time.Since(c.openedAt) < c.Cooldown {
+			return fmt.Errorf("%s: %w", c.Name, ErrCircuitOpen)
+		}
+		c.state = breakerHalfOpen
+		logger.GlobalBuffer.Info("LLM %s circuit half-open, probing", c.Name)
+		return nil
+	case breakerHalfOpen:
+		return fmt.Errorf("%s: %w (probe in flight)", c.Name, ErrCircuitOpen)
+	}
+	return nil
+}
+
+// record updates the circuit with the outcome of a call. A request the
+// provider rejected as invalid still shows it is up; a call the caller
+// cancelled says nothing and leaves the failure count alone.
+func (c *CircuitBreakerLLM) record(ctx context.Context, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil && ctx.Err() != nil {
+		if c.state == breakerHalfOpen {
+			c.state = breakerOpen // Let the next call probe again
+		}
+		return
+	}
+
+	var apiErr *APIError
+	if err == nil || (errors.As(err, &apiErr) && !apiErr.Retryable()) {
+		if c.state != breakerClosed {
+			logger.GlobalBuffer.Info("LLM %s circuit closed", c.Name)
+			countLLM(c.Name, "breaker_closed")
+		}
+		c.state = breakerClosed
+		c.failures = 0
+		return
+	}
+
+	c.failures++
+	if c.state == breakerHalfOpen || c.failures >= c.Threshold {
+		if c.state != breakerOpen {
+			logger.GlobalBuffer.Warn("LLM %s circuit opened after %d consecutive failures (last: %v)", c.Name, c.failures, err)
+			countLLM(c.Name, "breaker_opened")
+		}
+		c.state = breakerOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// NamedLLM is a provider in a fallback chain.
+type NamedLLM struct {
+	Name string
+	LLM  LLMTool
+}
+
+// FallbackLLM tries its providers in order and returns the first success.
+type FallbackLLM struct {
+	Providers []NamedLLM
+}
+
+func NewFallbackLLM(providers ...NamedLLM) *FallbackLLM {
+	return &FallbackLLM{Providers: providers}
+}
+
+func (f *FallbackLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return f.call(ctx, req, nil)
+}
+
+// GenerateStream implements StreamingLLM. Once a provider has delivered text
+// its failure is final.
+func (f *FallbackLLM) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	return f.call(ctx, req, onDelta)
+}
+
+func (f *FallbackLLM) call(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	streamed := false
+	var relay func(string)
+	if onDelta != nil {
+		relay = func(delta string) {
+			streamed = true
+			onDelta(delta)
+		}
+	}
+
+	var errs []error
+	for i, p := range f.Providers {
+		if i > 0 {
+			countLLM(p.Name, "fallbacks")
+			logger.GlobalBuffer.Warn("LLM %s failed, falling back to %s", f.Providers[i-1].Name, p.Name)
+		}
+		res, err := Stream(ctx, p.LLM, req, relay)
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		if ctx.Err() != nil || streamed {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no LLM providers configured")
+	}
+	return nil, fmt.Errorf("all LLM providers failed: %w", errors.Join(errs...))
+}
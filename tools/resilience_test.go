@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubLLM answers each call with the outcome for that call number, starting at 1.
+type stubLLM struct {
+	calls   int
+	outcome func(call int) (*LLMResponse, error)
+}
+
+func (s *stubLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	s.calls++
+	return s.outcome(s.calls)
+}
+
+// failing returns outcomes that fail with err for the first n calls and then succeed.
+func failing(n int, err error) func(int) (*LLMResponse, error) {
+	return func(call int) (*LLMResponse, error) {
+		if call <= n {
+			return nil, err
+		}
+		return &LLMResponse{Text: "ok"}, nil
+	}
+}
+
+var (
+	errUnavailable = &APIError{Provider: "stub", StatusCode: http.StatusServiceUnavailable}
+	errBadRequest  = &APIError{Provider: "stub", StatusCode: http.StatusBadRequest}
+)
+
+func newTestRetry(llm LLMTool) *RetryLLM {
+	r := NewRetryLLM("stub", llm)
+	r.BaseDelay = time.Millisecond
+	r.MaxDelay = 5 * time.Millisecond
+	return r
+}
+
+func TestRetryLLM(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"transient then success", 2, errUnavailable, 3, false},
+		{"transient past attempts", 5, errUnavailable, DefaultLLMAttempts, true},
+		{"permanent", 5, errBadRequest, 1, true},
+		{"network error", 5, errors.New("connection refused"), 1, true},
+		{"timeout", 1, context.DeadlineExceeded, 2, false},
+	}
+	for _, tt := range tests {
+		stub := &stubLLM{outcome: failing(tt.failures, tt.err)}
+		_, err := newTestRetry(stub).Generate(context.Background(), Prompt("", "hi"))
+		if (err != nil) != tt.wantErr || stub.calls != tt.wantCalls {
+			t.Errorf("%s: err = %v after %d calls, want error %v after %d", tt.name, err, stub.calls, tt.wantErr, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetryLLMDoesNotRetryStartedStream(t *testing.T) {
+	stub := &stubLLM{outcome: failing(5, errUnavailable)}
+	streaming := &streamingStub{stub: stub, partial: "Hel"}
+	var deltas []string
+	_, err := newTestRetry(streaming).GenerateStream(context.Background(), Prompt("", "hi"), func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err == nil || stub.calls != 1 || strings.Join(deltas, "") != "Hel" {
+		t.Errorf("err = %v after %d calls with deltas %q, want one failed call", err, stub.calls, deltas)
+	}
+}
+
+// streamingStub delivers partial before asking stub for the outcome.
+type streamingStub struct {
+	stub    *stubLLM
+	partial string
+}
+
+func (s *streamingStub) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	return s.stub.Generate(ctx, req)
+}
+
+func (s *streamingStub) GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	onDelta(s.partial)
+	return s.stub.Generate(ctx, req)
+}
+
+func TestRetryLLMBackoff(t *testing.T) {
+	r := NewRetryLLM("stub", nil)
+	for attempt := 1; attempt <= 8; attempt++ {
+		d := r.BaseDelay << (attempt - 1)
+		if d > r.MaxDelay {
+			d = r.MaxDelay
+		}
+		for i := 0; i < 20; i++ {
+			if got := r.backoff(attempt, errUnavailable); got < d/2 || got >= d {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v)", attempt, got, d/2, d)
+			}
+		}
+	}
+
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 7 * time.Second}
+	if got := r.backoff(1, limited); got != 7*time.Second {
+		t.Errorf("backoff with Retry-After = %v, want 7s", got)
+	}
+}
+
+func TestRetryLLMGivesUpBeforeDeadline(t *testing.T) {
+	stub := &stubLLM{outcome: failing(5, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour})}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := newTestRetry(stub).Generate(ctx, Prompt("", "hi")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if stub.calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("made %d calls in %v, want one immediate call", stub.calls, time.Since(start))
+	}
+}
+
+func TestRetryLLMRetryAfterLimit(t *testing.T) {
+	stub := &stubLLM{outcome: failing(5, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour})}
+	start := time.Now()
+	if _, err := newTestRetry(stub).Generate(context.Background(), Prompt("", "hi")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if stub.calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("made %d calls in %v, want to give up right away on a wait above MaxDelay", stub.calls, time.Since(start))
+	}
+
+	stub = &stubLLM{outcome: failing(1, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Millisecond})}
+	if _, err := newTestRetry(stub).Generate(context.Background(), Prompt("", "hi")); err != nil || stub.calls != 2 {
+		t.Errorf("got %v after %d calls, want a Retry-After within MaxDelay honoured", err, stub.calls)
+	}
+}
+
+func TestTimeoutLLM(t *testing.T) {
+	slow := &blockingLLM{}
+	_, err := NewTimeoutLLM("stub", slow, 10*time.Millisecond).Generate(context.Background(), Prompt("", "hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a deadline error", err)
+	}
+	if !isTransient(context.Background(), err) {
+		t.Error("a per-call timeout should be retried")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if isTransient(ctx, err) {
+		t.Error("errors after the caller gave up should not be retried")
+	}
+}
+
+// blockingLLM waits for its context to end.
+type blockingLLM struct{}
+
+func (blockingLLM) Generate(ctx context.Context, req LLMRequest) (*LLMResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCircuitBreakerLLM(t *testing.T) {
+	stub := &stubLLM{outcome: failing(3, errUnavailable)}
+	breaker := NewCircuitBreakerLLM("stub", stub)
+	breaker.Threshold = 3
+	breaker.Cooldown = 20 * time.Millisecond
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := breaker.Generate(ctx, Prompt("", "hi")); err == nil {
+			t.Fatalf("call %d succeeded", i+1)
+		}
+	}
+	if breaker.state != breakerOpen {
+		t.Fatalf("state after %d failures = %s, want open", breaker.Threshold, breaker.state)
+	}
+	if _, err := breaker.Generate(ctx, Prompt("", "hi")); !errors.Is(err, ErrCircuitOpen) || stub.calls != 3 {
+		t.Fatalf("open circuit: err = %v after %d calls, want ErrCircuitOpen without a call", err, stub.calls)
+	}
+
+	time.Sleep(breaker.Cooldown)
+	if _, err := breaker.Generate(ctx, Prompt("", "hi")); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if breaker.state != breakerClosed || breaker.failures != 0 {
+		t.Errorf("state after a successful probe = %s with %d failures, want closed", breaker.state, breaker.failures)
+	}
+}
+
+func TestCircuitBreakerLLMFailedProbeReopens(t *testing.T) {
+	stub := &stubLLM{outcome: failing(10, errUnavailable)}
+	breaker := NewCircuitBreakerLLM("stub", stub)
+	breaker.Threshold = 1
+	breaker.Cooldown = 10 * time.Millisecond
+
+	breaker.Generate(context.Background(), Prompt("", "hi"))
+	time.Sleep(breaker.Cooldown)
+	breaker.Generate(context.Background(), Prompt("", "hi"))
+	if breaker.state != breakerOpen || stub.calls != 2 {
+		t.Errorf("state after a failed probe = %s after %d calls, want open after 2", breaker.state, stub.calls)
+	}
+}
+
+func TestCircuitBreakerLLMIgnoresCallerErrors(t *testing.T) {
+	stub := &stubLLM{outcome: failing(10, errBadRequest)}
+	breaker := NewCircuitBreakerLLM("stub", stub)
+	breaker.Threshold = 2
+
+	for i := 0; i < 3; i++ {
+		breaker.Generate(context.Background(), Prompt("", "hi"))
+	}
+	if breaker.state != breakerClosed {
+		t.Errorf("invalid requests opened the circuit")
+	}
+
+	stub.outcome = failing(10, context.Canceled)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		breaker.Generate(ctx, Prompt("", "hi"))
+	}
+	if breaker.state != breakerClosed || breaker.failures != 0 {
+		t.Errorf("cancelled calls counted as %d failures", breaker.failures)
+	}
+}
+
+func TestFallbackLLM(t *testing.T) {
+	first := &stubLLM{outcome: failing(10, errUnavailable)}
+	second := &stubLLM{outcome: func(int) (*LLMResponse, error) { return &LLMResponse{Text: "second"}, nil }}
+	res, err := NewFallbackLLM(NamedLLM{"first", first}, NamedLLM{"second", second}).Generate(context.Background(), Prompt("", "hi"))
+	if err != nil || res.Text != "second" {
+		t.Fatalf("got %v, %v; want the second provider's answer", res, err)
+	}
+
+	other := &stubLLM{outcome: failing(10, errBadRequest)}
+	_, err = NewFallbackLLM(NamedLLM{"first", first}, NamedLLM{"other", other}).Generate(context.Background(), Prompt("", "hi"))
+	if err == nil || !strings.Contains(err.Error(), "first:") || !strings.Contains(err.Error(), "other:") || !errors.Is(err, errBadRequest) {
+		t.Errorf("err = %v, want both providers' errors", err)
+	}
+
+	if _, err := NewFallbackLLM().Generate(context.Background(), Prompt("", "hi")); err == nil {
+		t.Error("empty chain returned no error")
+	}
+}
+
+func TestFallbackLLMStopsAfterStreamedText(t *testing.T) {
+	first := &streamingStub{stub: &stubLLM{outcome: failing(10, errUnavailable)}, partial: "Hel"}
+	second := &stubLLM{outcome: failing(0, nil)}
+	_, err := NewFallbackLLM(NamedLLM{"first", first}, NamedLLM{"second", second}).
+		GenerateStream(context.Background(), Prompt("", "hi"), func(string) {})
+	if err == nil || second.calls != 0 {
+		t.Errorf("err = %v with %d fallback calls, want the stream's failure to be final", err, second.calls)
+	}
+}
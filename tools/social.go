@@ -3,6 +3,7 @@ package tools
 import (
 	"content-creator-agent/models"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -23,12 +24,58 @@ func NewMultiSocialClient() *MultiSocialClient {
 	}
 }
 
+// AddClient registers a client for a platform. Use RouteKey to register a
+// separate account for one locale of a platform.
 func (m *MultiSocialClient) AddClient(platform string, client SocialClient) {
 	m.Clients[platform] = client
 }
 
+// RouteKey is the client key for a platform account dedicated to a locale,
+// e.g. "twitter:es-MX". Without a locale it is just the platform.
+func RouteKey(platform, locale string) string {
+	if locale == "" {
+		return platform
+	}
+	return platform + ":" + locale
+}
+
+// LocaleEnvSuffix is appended to credential variable names for locale accounts,
+// e.g. "_ES_MX" for TWITTER_API_KEY_ES_MX.
+func LocaleEnvSuffix(locale string) string {
+	return "_" + strings.ToUpper(strings.NewReplacer("-", "_", ":", "_").Replace(locale))
+}
+
+// stubClient is implemented by clients that only simulate posting.
+type stubClient interface {
+	Stub() bool
+}
+
+// Platforms returns the names of the registered platforms in sorted order.
+// Platforms served only by stub clients are left out, as nothing posted there
+// is published.
+func (m *MultiSocialClient) Platforms() []string {
+	seen := make(map[string]bool)
+	var platforms []string
+	for key, client := range m.Clients {
+		if stub, ok := client.(stubClient); ok && stub.Stub() {
+			continue
+		}
+		p, _, _ := strings.Cut(key, ":")
+		if !seen[p] {
+			seen[p] = true
+			platforms = append(platforms, p)
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
 func (m *MultiSocialClient) Post(post *models.Post) error {
-	client, ok := m.Clients[post.Platform]
+	// A locale-specific account takes precedence over the platform default.
+	client, ok := m.Clients[RouteKey(post.Platform, post.Locale)]
+	if !ok {
+		client, ok = m.Clients[post.Platform]
+	}
 	if !ok {
 		// Fallback to all clients if platform is "LinkedIn/X" or similar generic string
 		if post.Platform == "LinkedIn/X" || post.Platform == "" {
@@ -43,12 +90,17 @@ func (m *MultiSocialClient) Post(post *models.Post) error {
 			}
 			return nil
 		}
+		// A registered mock client stands in for platforms without credentials.
+		if mock, ok := m.Clients["mock"]; ok {
+			return mock.Post(post)
+		}
 		return fmt.Errorf("no client configured for platform: %s", post.Platform)
 	}
 	return client.Post(post)
 }
 
 // MockSocialClient simulates posting by printing to console and updating status.
+// Posts get a "mock-" social ID so that analytics syncs pick them up.
 type MockSocialClient struct {
 	Platform string
 }
@@ -59,6 +111,9 @@ func (m *MockSocialClient) Post(post *models.Post) error {
 	fmt.Printf("Content:  %s\n", post.Content)
 	fmt.Printf("---------------------------\n")
 
+	if post.SocialID == "" {
+		post.SocialID = "mock-" + post.ID
+	}
 	post.Status = models.StatusPublished
 	post.UpdatedAt = time.Now()
 
@@ -73,6 +128,11 @@ func (m *MockSocialClient) Post(post *models.Post) error {
 	return nil
 }
 
+// Stub reports that the client publishes nowhere.
+func (m *MockSocialClient) Stub() bool {
+	return true
+}
+
 type InstagramClient struct{ MockSocialClient }
 type TikTokClient struct{ MockSocialClient }
 type ThreadsClient struct{ MockSocialClient }
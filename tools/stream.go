@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+)
+
+// StreamingLLM is implemented by LLM clients that can deliver a response as it
+// is generated. onDelta receives each chunk of text in order; the returned
+// response holds the full text and the final metadata.
+type StreamingLLM interface {
+	GenerateStream(ctx context.Context, req LLMRequest, onDelta func(string)) (*LLMResponse, error)
+}
+
+// Stream generates with llm, streaming to onDelta when the client supports it.
+// Other clients deliver the whole text as a single delta. A nil onDelta makes
+// a plain Generate call.
+func Stream(ctx context.Context, llm LLMTool, req LLMRequest, onDelta func(string)) (*LLMResponse, error) {
+	if onDelta == nil {
+		return llm.Generate(ctx, req)
+	}
+	if s, ok := llm.(StreamingLLM); ok {
+		return s.GenerateStream(ctx, req, onDelta)
+	}
+	res, err := llm.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	onDelta(res.Text)
+	return res, nil
+}
+
+// maxSSELine bounds a single line of a server-sent event stream.
+const maxSSELine = 1024 * 1024
+
+// readSSE reads a server-sent event stream and calls onData with the data of
+// each event. Multi-line data is joined with newlines; the "[DONE]" sentinel
+// some providers send is skipped.
+func readSSE(r io.Reader, onData func([]byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSSELine)
+
+	var data [][]byte
+	dispatch := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		payload := bytes.Join(data, []byte("\n"))
+		data = nil
+		if string(payload) == "[DONE]" {
+			return nil
+		}
+		return onData(payload)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			if err := dispatch(); err != nil {
+				return err
+			}
+			continue
+		}
+		if value, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			data = append(data, bytes.Clone(bytes.TrimPrefix(value, []byte(" "))))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return dispatch()
+}
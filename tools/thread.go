@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// TweetLimit is the maximum length of a single tweet.
+const TweetLimit = 280
+
+// threadCounterReserve is the room kept free in each tweet for a " (NN/NN)" counter.
+const threadCounterReserve = 8
+
+var sentenceEndRe = regexp.MustCompile(`([.!?…]+["')\]]*)\s+`)
+
+// SplitThread breaks content into numbered tweets of at most limit characters.
+// Text is split on sentence boundaries where possible, falling back to word and
+// then character boundaries for sentences that are too long on their own.
+// Content that already fits is returned as a single, unnumbered tweet.
+func SplitThread(content string, limit int) []string {
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) <= limit {
+		return []string{content}
+	}
+
+	budget := limit - threadCounterReserve
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	add := func(piece, sep string) {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+len(sep)+utf8.RuneCountInString(piece) > budget {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString(sep)
+		}
+		current.WriteString(piece)
+	}
+
+	for _, sentence := range splitSentences(content) {
+		if utf8.RuneCountInString(sentence) <= budget {
+			add(sentence, " ")
+			continue
+		}
+		for _, word := range strings.Fields(sentence) {
+			for utf8.RuneCountInString(word) > budget {
+				runes := []rune(word)
+				flush()
+				chunks = append(chunks, string(runes[:budget]))
+				word = string(runes[budget:])
+			}
+			add(word, " ")
+		}
+	}
+	flush()
+
+	tweets := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		tweets[i] = fmt.Sprintf("%s (%d/%d)", chunk, i+1, len(chunks))
+	}
+	return tweets
+}
+
+// splitSentences splits text after sentence-ending punctuation and on blank lines.
+func splitSentences(text string) []string {
+	var sentences []string
+	for _, para := range strings.Split(text, "\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		marked := sentenceEndRe.ReplaceAllString(para, "$1\x00")
+		for _, s := range strings.Split(marked, "\x00") {
+			if s = strings.TrimSpace(s); s != "" {
+				sentences = append(sentences, s)
+			}
+		}
+	}
+	return sentences
+}
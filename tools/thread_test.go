@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitThreadShortContent(t *testing.T) {
+	got := SplitThread("  Short and sweet.  ", TweetLimit)
+	if len(got) != 1 || got[0] != "Short and sweet." {
+		t.Errorf("got %q, want a single unnumbered tweet", got)
+	}
+
+	exact := strings.Repeat("a", TweetLimit)
+	if got := SplitThread(exact, TweetLimit); len(got) != 1 {
+		t.Errorf("content of exactly the limit split into %d tweets", len(got))
+	}
+}
+
+func TestSplitThreadSentences(t *testing.T) {
+	var sentences []string
+	for i := 1; i <= 12; i++ {
+		sentences = append(sentences, fmt.Sprintf("Sentence number %d is here to fill the thread with words.", i))
+	}
+	content := strings.Join(sentences, " ")
+
+	tweets := SplitThread(content, TweetLimit)
+	if len(tweets) < 2 {
+		t.Fatalf("got %d tweets, want a thread", len(tweets))
+	}
+	var rebuilt []string
+	for i, tweet := range tweets {
+		if n := utf8.RuneCountInString(tweet); n > TweetLimit {
+			t.Errorf("tweet %d has %d characters", i+1, n)
+		}
+		counter := fmt.Sprintf(" (%d/%d)", i+1, len(tweets))
+		if !strings.HasSuffix(tweet, counter) {
+			t.Errorf("tweet %d = %q, want suffix %q", i+1, tweet, counter)
+		}
+		body := strings.TrimSuffix(tweet, counter)
+		if !strings.HasSuffix(body, ".") {
+			t.Errorf("tweet %d breaks mid-sentence: %q", i+1, body)
+		}
+		rebuilt = append(rebuilt, body)
+	}
+	if strings.Join(rebuilt, " ") != content {
+		t.Error("thread does not reproduce the content")
+	}
+}
+
+func TestSplitThreadLongSentencesAndWords(t *testing.T) {
+	long := strings.Repeat("word ", 100) + strings.Repeat("x", 600)
+	tweets := SplitThread(long, TweetLimit)
+
+	var words int
+	var xs int
+	for i, tweet := range tweets {
+		if n := utf8.RuneCountInString(tweet); n > TweetLimit {
+			t.Errorf("tweet %d has %d characters", i+1, n)
+		}
+		body := strings.TrimSuffix(tweet, fmt.Sprintf(" (%d/%d)", i+1, len(tweets)))
+		for _, f := range strings.Fields(body) {
+			if f == "word" {
+				words++
+			} else {
+				xs += strings.Count(f, "x")
+			}
+		}
+	}
+	if words != 100 || xs != 600 {
+		t.Errorf("thread kept %d words and %d characters of the long word, want 100 and 600", words, xs)
+	}
+}
+
+func TestSplitThreadCountsRunes(t *testing.T) {
+	// 273 characters but 543 bytes.
+	content := strings.Repeat("é", 200) + ". " + strings.Repeat("ü", 70) + "."
+	if got := SplitThread(content, TweetLimit); len(got) != 1 {
+		t.Errorf("got %d tweets, want 1", len(got))
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("First one. Second one!  \n\n(Third?) \"Quoted.\" Last")
+	want := []string{"First one.", "Second one!", "(Third?)", "\"Quoted.\"", "Last"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"bytes"
 	"content-creator-agent/models"
+	"content-creator-agent/tools/logger"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
@@ -33,21 +34,85 @@ func NewTwitterClient(apiKey, apiSecret, accessToken, accessSecret string) *Twit
 	}
 }
 
-// Post sends a tweet via the X API v2.
+// Post sends a tweet via the X API v2. Content longer than a single tweet is
+// published as a numbered thread.
 func (t *TwitterClient) Post(post *models.Post) error {
+	tweets := SplitThread(post.Content, TweetLimit)
+	if len(tweets) > 1 {
+		return t.PostThread(post, tweets)
+	}
+
+	id, err := t.createTweet(post.Content, "")
+	if err != nil {
+		return err
+	}
+
+	post.SocialID = id
+	post.Status = models.StatusPublished
+	post.UpdatedAt = time.Now()
+	return nil
+}
+
+// PostThread publishes tweets as a reply chain and records every tweet ID on the post.
+// If a tweet fails mid-thread the already published tweets are deleted. The rollback
+// is best-effort: tweets that could not be deleted are left in post.ThreadIDs of the
+// failed post for manual cleanup, and a retry publishes the whole thread again.
+func (t *TwitterClient) PostThread(post *models.Post, tweets []string) error {
+	var ids []string
+	for i := range tweets {
+		replyTo := ""
+		if i > 0 {
+			replyTo = ids[i-1]
+		}
+
+		id, err := t.createTweet(tweets[i], replyTo)
+		if err == nil && id == "" {
+			err = fmt.Errorf("X API returned no tweet ID to reply to")
+		}
+		if err != nil {
+			post.ThreadIDs = t.rollbackThread(ids)
+			return fmt.Errorf("thread failed at tweet %d/%d: %w", i+1, len(tweets), err)
+		}
+		ids = append(ids, id)
+	}
+
+	post.ThreadIDs = ids
+	post.SocialID = ids[0]
+	post.Status = models.StatusPublished
+	post.UpdatedAt = time.Now()
+	return nil
+}
+
+// rollbackThread deletes the tweets of a failed thread, newest first, and
+// returns the IDs it could not delete.
+func (t *TwitterClient) rollbackThread(posted []string) []string {
+	for i := len(posted) - 1; i >= 0; i-- {
+		if err := t.deleteTweet(posted[i]); err != nil {
+			logger.GlobalBuffer.Warn("Could not delete tweet %s of failed thread: %v", posted[i], err)
+			return posted[:i+1]
+		}
+	}
+	return nil
+}
+
+// createTweet publishes a single tweet, optionally as a reply, and returns its ID.
+func (t *TwitterClient) createTweet(text, inReplyTo string) (string, error) {
 	apiURL := "https://api.twitter.com/2/tweets"
 
-	payload := map[string]string{
-		"text": post.Content,
+	payload := map[string]interface{}{
+		"text": text,
+	}
+	if inReplyTo != "" {
+		payload["reply"] = map[string]string{"in_reply_to_tweet_id": inReplyTo}
 	}
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal tweet payload: %w", err)
+		return "", fmt.Errorf("failed to marshal tweet payload: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -59,13 +124,13 @@ func (t *TwitterClient) Post(post *models.Post) error {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request to X failed: %w", err)
+		return "", fmt.Errorf("request to X failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("X API returned error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("X API returned error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var tweetResp struct {
@@ -73,12 +138,30 @@ func (t *TwitterClient) Post(post *models.Post) error {
 			ID string `json:"id"`
 		} `json:"data"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&tweetResp); err == nil {
-		post.SocialID = tweetResp.Data.ID
+	json.NewDecoder(resp.Body).Decode(&tweetResp)
+	return tweetResp.Data.ID, nil
+}
+
+// deleteTweet removes a published tweet.
+func (t *TwitterClient) deleteTweet(id string) error {
+	apiURL := fmt.Sprintf("https://api.twitter.com/2/tweets/%s", id)
+	req, err := http.NewRequest("DELETE", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Authorization", t.generateOAuthHeader("DELETE", apiURL, nil))
 
-	post.Status = models.StatusPublished
-	post.UpdatedAt = time.Now()
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to X failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("X API returned error (status %d): %s", resp.StatusCode, string(body))
+	}
 	return nil
 }
 